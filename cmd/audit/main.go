@@ -0,0 +1,46 @@
+package main
+
+import (
+	"Go-Starter-Template/cmd/config"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/pkg/audit"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Walks the audit log hash chain and exits non-zero when it is broken.
+//
+//	go run cmd/audit/main.go -anchor <head hash from the last run>
+func main() {
+	batchSize := flag.Int("batch", 1000, "rows read per query")
+	anchor := flag.String("anchor", "", "head hash printed by an earlier run, detects rows removed from the end")
+	flag.Parse()
+
+	utils.LoadConfig()
+	db, err := config.ConnectDB()
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	auditService := audit.NewAuditService(audit.NewAuditRepository(db))
+	result, err := auditService.Verify(context.Background(), *batchSize, *anchor)
+	if err != nil {
+		log.Fatalf("Error verifying audit log: %v", err)
+	}
+
+	if !result.Valid {
+		fmt.Printf("audit log BROKEN at id %d: %s\n", result.BrokenAt, result.Reason)
+		fmt.Printf("%d rows verified before it\n", result.Checked)
+		os.Exit(1)
+	}
+
+	fmt.Printf("audit log intact: %d rows verified\n", result.Checked)
+	fmt.Printf("head: id %d hash %s\n", result.HeadID, result.HeadHash)
+	if *anchor != "" && !result.AnchorFound {
+		fmt.Println("anchor hash not found, the log was truncated or rewritten")
+		os.Exit(1)
+	}
+}
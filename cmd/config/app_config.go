@@ -5,11 +5,27 @@ import (
 	"Go-Starter-Template/internal/api/routes"
 	"Go-Starter-Template/internal/middleware"
 	"Go-Starter-Template/internal/utils"
+	provider "Go-Starter-Template/internal/utils/oauth"
+	gateway "Go-Starter-Template/internal/utils/payment"
+	"Go-Starter-Template/internal/utils/ratelimit"
+	"Go-Starter-Template/internal/utils/scheduler"
 	"Go-Starter-Template/internal/utils/storage"
+	"Go-Starter-Template/pkg/admin"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/dataexport"
 	"Go-Starter-Template/pkg/food"
 	"Go-Starter-Template/pkg/jwt"
-	"Go-Starter-Template/pkg/midtrans"
+	"Go-Starter-Template/pkg/lockout"
+	"Go-Starter-Template/pkg/mfa"
+	"Go-Starter-Template/pkg/oauth"
+	"Go-Starter-Template/pkg/onetimetoken"
+	"Go-Starter-Template/pkg/payment"
+	"Go-Starter-Template/pkg/rbac"
+	"Go-Starter-Template/pkg/session"
+	"Go-Starter-Template/pkg/transaction"
+	"Go-Starter-Template/pkg/upload"
 	"Go-Starter-Template/pkg/user"
+	"context"
 	"os"
 	"time"
 
@@ -17,6 +33,7 @@ import (
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"gorm.io/gorm"
 )
 
@@ -24,8 +41,9 @@ func NewApp(db *gorm.DB) (*fiber.App, error) {
 	utils.InitValidator()
 	app := fiber.New(fiber.Config{
 		EnablePrintRoutes: true,
+		// phone photos are larger than the 4MB default, see upload rules
+		BodyLimit: 25 << 20,
 	})
-	middlewares := middleware.NewMiddleware()
 	validator := utils.Validate
 
 	// setting up logging and limiter
@@ -41,7 +59,9 @@ func NewApp(db *gorm.DB) (*fiber.App, error) {
 	if err != nil {
 		log.Fatalf("error opening file: %v", err)
 	}
+	app.Use(requestid.New())
 	app.Use(logger.New(logger.Config{
+		Format:     "${time} | ${locals:requestid} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${error}\n",
 		TimeFormat: "2006-01-02 15:04:05",
 		TimeZone:   "Asia/Jakarta",
 		Output:     file,
@@ -53,35 +73,111 @@ func NewApp(db *gorm.DB) (*fiber.App, error) {
 	}))
 
 	// utils
-	s3 := storage.NewAwsS3()
+	blobStore, err := storage.NewBlobStore(storage.LoadStorageConfig())
+	if err != nil {
+		return nil, err
+	}
+	if server, ok := blobStore.(storage.FileServer); ok {
+		app.Get(server.Route()+"/*", server.Serve)
+		app.Put(server.Route()+"/*", server.Receive)
+	}
+	limiterStorage := ratelimit.NewStorage(db)
 
 	// Repository
 	userRepository := user.NewUserRepository(db)
-	midtransRepository := midtrans.NewMidtransRepository(db)
+	paymentRepository := payment.NewPaymentRepository(db)
 	foodRepository := food.NewFoodRepository(db)
+	transactionRepository := transaction.NewTransactionRepository(db)
+	sessionRepository := session.NewSessionRepository(db)
+	oauthRepository := oauth.NewOAuthRepository(db)
+	mfaRepository := mfa.NewMFARepository(db)
+	oneTimeTokenRepository := onetimetoken.NewOneTimeTokenRepository(db)
+	lockoutRepository := lockout.NewLockoutRepository(db)
+	adminRepository := admin.NewAdminRepository(db)
+	rbacRepository := rbac.NewRBACRepository(db)
+	auditRepository := audit.NewAuditRepository(db)
+	dataExportRepository := dataexport.NewDataExportRepository(db)
 
 	// Service
-	jwtService := jwt.NewJWTService()
-	userService := user.NewUserService(userRepository, jwtService, s3)
-	midtransService := midtrans.NewMidtransService(
-		midtransRepository,
+	jwtService, err := jwt.NewJWTService()
+	if err != nil {
+		return nil, err
+	}
+	auditService := audit.NewAuditService(auditRepository)
+	sessionService := session.NewSessionService(sessionRepository, jwtService)
+	mfaService := mfa.NewMFAService(mfaRepository, sessionService, jwtService, auditService)
+	oneTimeTokenService := onetimetoken.NewOneTimeTokenService(oneTimeTokenRepository)
+	lockoutService := lockout.NewLockoutService(lockoutRepository, lockout.DefaultAccountPolicy, lockout.DefaultIPPolicy)
+	uploadService := upload.NewUploadService(blobStore)
+	userService := user.NewUserService(userRepository, oneTimeTokenService, sessionService, mfaService, lockoutService, auditService, blobStore, uploadService)
+	transactionService := transaction.NewTransactionService(transactionRepository, blobStore)
+	paymentGateways := gateway.NewGateways(
+		gateway.NewMidtransGateway(gateway.LoadMidtransConfig()),
+		gateway.NewXenditGateway(gateway.LoadXenditConfig()),
+	)
+	paymentService := payment.NewPaymentService(
+		paymentRepository,
 		userRepository,
+		transactionService,
+		auditService,
+		paymentGateways,
 	)
-	foodService := food.NewFoodService(foodRepository, s3)
+	foodService := food.NewFoodService(foodRepository, auditService, blobStore, uploadService)
+	oauthService := oauth.NewOAuthService(
+		oauthRepository,
+		userRepository,
+		mfaService,
+		provider.NewProviders(provider.NewOIDCProvider(provider.LoadGoogleConfig())),
+	)
+
+	adminService := admin.NewAdminService(adminRepository, sessionService, auditService)
+	rbacService := rbac.NewRBACService(rbacRepository, auditService)
+	dataExportService := dataexport.NewDataExportService(dataExportRepository, auditService, blobStore)
+
+	// background jobs
+	go scheduler.Every(context.Background(), "payment-reconcile", gateway.LoadPaymentConfig().ReconcileInterval, paymentService.ReconcilePendingTransactions)
+	go scheduler.Every(context.Background(), "oauth-state-purge", time.Hour, oauthService.PurgeExpiredStates)
+	go scheduler.Every(context.Background(), "one-time-token-purge", 24*time.Hour, oneTimeTokenService.PurgeExpiredTokens)
+	go scheduler.Every(context.Background(), "login-throttle-purge", time.Hour, lockoutService.PurgeStale)
+	go scheduler.Every(context.Background(), "rate-limit-purge", 10*time.Minute, limiterStorage.PurgeExpired)
+	go scheduler.Every(context.Background(), "data-export-worker", time.Minute, dataExportService.ProcessPendingExports)
+	go scheduler.Every(context.Background(), "data-export-purge", time.Hour, dataExportService.PurgeExpiredExports)
+	go scheduler.Every(context.Background(), "food-trash-purge", time.Hour, foodService.PurgeExpiredTrash)
+	go scheduler.Every(context.Background(), "account-deletion-purge", time.Hour, userService.PurgeDeletedAccounts)
+
+	middlewares := middleware.NewMiddleware(sessionService, mfaService, rbacService, limiterStorage)
 
 	// Handler
-	userHandler := handlers.NewUserHandler(userService, validator, jwtService)
-	midtransHandler := handlers.NewMidtransHandler(midtransService, validator)
+	userHandler := handlers.NewUserHandler(userService, validator)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, validator)
 	foodHandler := handlers.NewFoodHandler(foodService, validator)
+	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	authHandler := handlers.NewAuthHandler(sessionService, jwtService, validator)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, validator)
+	mfaHandler := handlers.NewMFAHandler(mfaService, validator)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(adminService, validator)
+	rbacHandler := handlers.NewRBACHandler(rbacService, validator)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService)
+	uploadHandler := handlers.NewUploadHandler(uploadService, validator)
 
 	// routes
 	routesConfig := routes.Config{
-		App:             app,
-		UserHandler:     userHandler,
-		MidtransHandler: midtransHandler,
-		FoodHandler:     foodHandler,
-		Middleware:      middlewares,
-		JWTService:      jwtService,
+		App:                app,
+		UserHandler:        userHandler,
+		PaymentHandler:     paymentHandler,
+		FoodHandler:        foodHandler,
+		TransactionHandler: transactionHandler,
+		AuthHandler:        authHandler,
+		OAuthHandler:       oauthHandler,
+		MFAHandler:         mfaHandler,
+		AdminHandler:       adminHandler,
+		RBACHandler:        rbacHandler,
+		AuditHandler:       auditHandler,
+		DataExportHandler:  dataExportHandler,
+		UploadHandler:      uploadHandler,
+		Middleware:         middlewares,
+		JWTService:         jwtService,
 	}
 	routesConfig.Setup()
 	return app, nil
@@ -30,6 +30,11 @@ func DatabaseSetUp() (*gorm.DB, error) {
 		if err := migration.Migrate(db); err != nil {
 			return nil, err
 		}
+		// routes check permissions, a deployment that never ran -seed
+		// still needs the default roles
+		if err := seeder.SeedingRBAC(db); err != nil {
+			return nil, err
+		}
 	}
 	if *seedFlag {
 		if err := seeder.Seed(db); err != nil {
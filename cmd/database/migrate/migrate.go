@@ -13,6 +13,10 @@ func Migrate(db *gorm.DB) error {
 	db.Exec("CREATE EXTENSION IF NOT EXISTS \"earthdistance\" CASCADE;")
 	db.Exec("CREATE EXTENSION IF NOT EXISTS \"cube\";")
 
+	if err := db.AutoMigrate(&entities2.Permission{}, &entities2.Role{}); err != nil {
+		log.Fatalf("Error migrating role database: %v", err)
+		return err
+	}
 	if err := db.AutoMigrate(&entities2.User{}); err != nil {
 		log.Fatalf("Error migrating user database: %v", err)
 		return err
@@ -22,6 +26,47 @@ func Migrate(db *gorm.DB) error {
 		return err
 	}
 
+	if err := db.AutoMigrate(&entities2.PaymentEvent{}); err != nil {
+		log.Fatalf("Error migrating payment event database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.Session{}); err != nil {
+		log.Fatalf("Error migrating session database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.UserIdentity{}); err != nil {
+		log.Fatalf("Error migrating user identity database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.OAuthState{}); err != nil {
+		log.Fatalf("Error migrating oauth state database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.RecoveryCode{}); err != nil {
+		log.Fatalf("Error migrating recovery code database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.MFAPolicy{}); err != nil {
+		log.Fatalf("Error migrating mfa policy database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.OneTimeToken{}); err != nil {
+		log.Fatalf("Error migrating one time token database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.PasswordHistory{}); err != nil {
+		log.Fatalf("Error migrating password history database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.LoginThrottle{}); err != nil {
+		log.Fatalf("Error migrating login throttle database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.RateLimitEntry{}); err != nil {
+		log.Fatalf("Error migrating rate limit database: %v", err)
+		return err
+	}
+
 	if err := db.AutoMigrate(&entities2.FoodItem{}); err != nil {
 		log.Fatalf("Error migrating food item database: %v", err)
 		return err
@@ -30,6 +75,38 @@ func Migrate(db *gorm.DB) error {
 		log.Fatalf("Error migrating receipt scan database: %v", err)
 		return err
 	}
+	if err := db.AutoMigrate(&entities2.ReceiptPage{}); err != nil {
+		log.Fatalf("Error migrating receipt page database: %v", err)
+		return err
+	}
+	if err := db.AutoMigrate(&entities2.ReceiptCorrection{}); err != nil {
+		log.Fatalf("Error migrating receipt correction database: %v", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&entities2.DataExport{}); err != nil {
+		log.Fatalf("Error migrating data export database: %v", err)
+		return err
+	}
+
+	if err := db.AutoMigrate(&entities2.AuditLog{}); err != nil {
+		log.Fatalf("Error migrating audit log database: %v", err)
+		return err
+	}
+	// the hash chain detects tampering, the trigger stops it through the app role
+	if err := db.Exec(`
+		CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append only';
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+		CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+	`).Error; err != nil {
+		log.Fatalf("Error creating audit log trigger: %v", err)
+		return err
+	}
 
 	fmt.Println("Database migration complete")
 	return nil
@@ -0,0 +1,78 @@
+package seeder
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"gorm.io/gorm"
+	"log"
+)
+
+var permissions = map[string]string{
+	domain.PermAll:               "every permission",
+	domain.PermFoodRead:          "read own food items",
+	domain.PermFoodWrite:         "add and update own food items",
+	domain.PermFoodDelete:        "delete own food items",
+	domain.PermFoodDeleteAny:     "delete any user's food items",
+	domain.PermUserRead:          "search and view users",
+	domain.PermUserSuspend:       "suspend and unsuspend users",
+	domain.PermUserVerify:        "verify users manually",
+	domain.PermSubscriptionEdit:  "grant and revoke subscriptions",
+	domain.PermTransactionRead:   "view every transaction and its payment events",
+	domain.PermTransactionRefund: "refund transactions",
+	domain.PermStatisticsRead:    "view platform statistics",
+	domain.PermMFAPolicyEdit:     "view and change mfa policies",
+	domain.PermRBACManage:        "manage roles and role assignments",
+	domain.PermAuditRead:         "read the audit log",
+}
+
+var userPermissions = []string{domain.PermFoodRead, domain.PermFoodWrite, domain.PermFoodDelete}
+
+var roles = []struct {
+	name        string
+	description string
+	permissions []string
+}{
+	{domain.RoleUser, "every registered user", userPermissions},
+	{domain.RolePremium, "users with an active subscription", userPermissions},
+	{domain.RoleSupport, "customer support staff", []string{
+		domain.PermUserRead,
+		domain.PermUserVerify,
+		domain.PermTransactionRead,
+		domain.PermStatisticsRead,
+	}},
+	{domain.RoleAdmin, "full access", []string{domain.PermAll}},
+}
+
+// SeedingRBAC creates the default roles and permissions. Running it again
+// only adds what is missing, it never takes a permission away from a role.
+func SeedingRBAC(db *gorm.DB) error {
+	byName := make(map[string]entities.Permission, len(permissions))
+	for name, description := range permissions {
+		permission := entities.Permission{Name: name, Description: description}
+		if err := db.Where("name = ?", name).FirstOrCreate(&permission).Error; err != nil {
+			log.Printf("Error seeding permission %s: %v", name, err)
+			return err
+		}
+		byName[name] = permission
+	}
+
+	for _, r := range roles {
+		role := entities.Role{Name: r.name, Description: r.description}
+		if err := db.Where("name = ?", r.name).FirstOrCreate(&role).Error; err != nil {
+			log.Printf("Error seeding role %s: %v", r.name, err)
+			return err
+		}
+
+		granted := make([]entities.Permission, 0, len(r.permissions))
+		for _, name := range r.permissions {
+			granted = append(granted, byName[name])
+		}
+		if err := db.Model(&role).Association("Permissions").Append(granted); err != nil {
+			log.Printf("Error granting permissions to role %s: %v", r.name, err)
+			return err
+		}
+	}
+
+	log.Println("seeding roles completed successfully!")
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 )
 
 func Seed(db *gorm.DB) error {
+	if err := SeedingRBAC(db); err != nil {
+		return err
+	}
 	if err := SeedingUser(db); err != nil {
 		return err
 	}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/internal/utils/imaging"
+	"Go-Starter-Template/internal/utils/itemmatch"
+	"Go-Starter-Template/internal/utils/pdfpages"
+	"Go-Starter-Template/internal/utils/storage"
+	"Go-Starter-Template/pkg/food"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+const pdfContentType = "application/pdf"
+
+type (
+	// goldenCase is one receipt of the dataset. Header fields left out are
+	// not scored.
+	goldenCase struct {
+		Files        []string       `json:"files"`
+		MerchantName string         `json:"merchant_name,omitempty"`
+		PurchaseDate string         `json:"purchase_date,omitempty"` // YYYY-MM-DD
+		Total        *float64       `json:"total,omitempty"`
+		Items        []expectedItem `json:"items"`
+	}
+
+	// expectedItem is a food item on the receipt, fields left out are not
+	// scored.
+	expectedItem struct {
+		Name        string  `json:"name"`
+		Quantity    *int    `json:"quantity,omitempty"`
+		UnitMeasure *string `json:"unit_measure,omitempty"`
+		Price       *string `json:"price,omitempty"`
+		IsPackaged  *bool   `json:"is_packaged,omitempty"`
+		Category    *string `json:"category,omitempty"`
+	}
+
+	score struct {
+		Correct  int     `json:"correct"`
+		Checked  int     `json:"checked"`
+		Accuracy float64 `json:"accuracy"`
+	}
+
+	counts struct {
+		Expected  int     `json:"expected"`
+		Read      int     `json:"read"`
+		Matched   int     `json:"matched"`
+		Precision float64 `json:"precision"`
+		Recall    float64 `json:"recall"`
+	}
+
+	caseResult struct {
+		Name  string `json:"name"`
+		Error string `json:"error,omitempty"`
+		counts
+	}
+
+	report struct {
+		Cases  []caseResult      `json:"cases"`
+		Total  counts            `json:"total"`
+		Fields map[string]*score `json:"fields"`
+	}
+)
+
+// fields is the order fields are reported in.
+var fields = []string{"name", "quantity", "unit_measure", "price", "is_packaged", "category", "merchant_name", "purchase_date", "total"}
+
+// Replays a golden dataset of receipts through the vision provider the way
+// UploadReceipt reads them and reports precision, recall and per field
+// accuracy, to see what a prompt or model change does.
+//
+//	go run cmd/ocreval/main.go -dataset <dir> [-json]
+//
+// Every <case>.json in the dataset is a receipt: the files it was sent as,
+// relative to the dataset, and the food items and header expected from it.
+// Items read and expected pair up by name, see itemmatch.Match.
+func main() {
+	dataset := flag.String("dataset", "", "directory of <case>.json files and the receipts they name")
+	asJSON := flag.Bool("json", false, "print the report as JSON, for comparing runs")
+	flag.Parse()
+	if *dataset == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	utils.LoadConfig()
+	casePaths, err := filepath.Glob(filepath.Join(*dataset, "*.json"))
+	if err != nil || len(casePaths) == 0 {
+		log.Fatalf("No cases found in %s", *dataset)
+	}
+
+	imageConfig := imaging.LoadConfig()
+	imageConfig.Variants = nil
+	result := report{Fields: make(map[string]*score, len(fields))}
+	for _, field := range fields {
+		result.Fields[field] = &score{}
+	}
+
+	failed := false
+	for _, casePath := range casePaths {
+		name := strings.TrimSuffix(filepath.Base(casePath), ".json")
+		c, err := evaluate(context.Background(), *dataset, casePath, imageConfig, result.Fields)
+		c.Name = name
+		if err != nil {
+			c.Error = err.Error()
+			failed = true
+		}
+		result.Cases = append(result.Cases, c)
+		result.Total.Expected += c.Expected
+		result.Total.Read += c.Read
+		result.Total.Matched += c.Matched
+	}
+	result.Total.finish()
+	for _, s := range result.Fields {
+		s.Accuracy = ratio(s.Correct, s.Checked)
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding report: %v", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		printReport(result)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// evaluate reads one case and adds its field scores to scores.
+func evaluate(ctx context.Context, dataset, casePath string, imageConfig imaging.Config, scores map[string]*score) (caseResult, error) {
+	var c caseResult
+	data, err := os.ReadFile(casePath)
+	if err != nil {
+		return c, err
+	}
+	var golden goldenCase
+	if err := json.Unmarshal(data, &golden); err != nil {
+		return c, err
+	}
+	c.Expected = len(golden.Items)
+
+	pages, err := loadPages(dataset, golden.Files, imageConfig)
+	if err != nil {
+		return c, err
+	}
+	header, items, err := food.ReadReceipt(ctx, pages)
+	if err != nil {
+		return c, err
+	}
+	c.Read = len(items)
+
+	expectedNames := make([]string, len(golden.Items))
+	for i, item := range golden.Items {
+		expectedNames[i] = item.Name
+	}
+	readNames := make([]string, len(items))
+	for j, item := range items {
+		readNames[j] = fmt.Sprint(item["name"])
+	}
+	pairs := itemmatch.Match(expectedNames, readNames)
+	c.Matched = len(pairs)
+	c.finish()
+
+	for i, j := range pairs {
+		expected, read := golden.Items[i], items[j]
+		scores["name"].add(itemmatch.Normalize(expected.Name) == itemmatch.Normalize(readNames[j]))
+		if expected.Quantity != nil {
+			scores["quantity"].add(*expected.Quantity == quantity(read["quantity"]))
+		}
+		if expected.UnitMeasure != nil {
+			scores["unit_measure"].add(itemmatch.Normalize(*expected.UnitMeasure) == itemmatch.Normalize(text(read["unit_measure"])))
+		}
+		if expected.Price != nil {
+			scores["price"].add(digits(*expected.Price) == digits(text(read["price"])))
+		}
+		if expected.IsPackaged != nil {
+			isPackaged, _ := read["is_packaged"].(bool)
+			scores["is_packaged"].add(*expected.IsPackaged == isPackaged)
+		}
+		if expected.Category != nil {
+			scores["category"].add(itemmatch.Normalize(*expected.Category) == itemmatch.Normalize(text(read["category"])))
+		}
+	}
+
+	if golden.MerchantName != "" {
+		scores["merchant_name"].add(itemmatch.Normalize(golden.MerchantName) == itemmatch.Normalize(header.MerchantName))
+	}
+	if golden.PurchaseDate != "" {
+		scores["purchase_date"].add(header.PurchasedAt != nil && header.PurchasedAt.Format("2006-01-02") == golden.PurchaseDate)
+	}
+	if golden.Total != nil {
+		scores["total"].add(header.Total != nil && math.Abs(*header.Total-*golden.Total) < 0.005)
+	}
+	return c, nil
+}
+
+// loadPages turns the files of a receipt into what UploadReceipt sends the
+// vision provider: the vision copy of each image and of each PDF page that
+// is a picture, other PDF pages as they are.
+func loadPages(dataset string, files []string, imageConfig imaging.Config) ([]storage.File, error) {
+	var pages []storage.File
+	vision := func(data []byte) error {
+		result, err := imaging.Process(data, imageConfig)
+		if err != nil {
+			return err
+		}
+		pages = append(pages, storage.File{Data: result.Vision.Data, ContentType: imaging.ContentType})
+		return nil
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dataset, name))
+		if err != nil {
+			return nil, err
+		}
+		file, err := storage.CheckFile(data, storage.AllowImagePdf...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if file.ContentType != pdfContentType {
+			if err := vision(data); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+
+		pdfPages, err := pdfpages.Split(data, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, page := range pdfPages {
+			if page.Image == nil {
+				pages = append(pages, storage.File{Data: page.PDF, ContentType: pdfContentType})
+				continue
+			}
+			if err := vision(page.Image); err != nil {
+				return nil, fmt.Errorf("%s page %d: %w", name, page.Number, err)
+			}
+		}
+	}
+	if len(pages) == 0 {
+		return nil, storage.ErrNoFile
+	}
+	return pages, nil
+}
+
+func printReport(result report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "case\texpected\tread\tmatched\tprecision\trecall\t")
+	for _, c := range result.Cases {
+		if c.Error != "" {
+			fmt.Fprintf(w, "%s\t%d\t-\t-\t-\t-\t%s\n", c.Name, c.Expected, c.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.2f\t%.2f\t\n", c.Name, c.Expected, c.Read, c.Matched, c.Precision, c.Recall)
+	}
+	t := result.Total
+	fmt.Fprintf(w, "total\t%d\t%d\t%d\t%.2f\t%.2f\t\n", t.Expected, t.Read, t.Matched, t.Precision, t.Recall)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "field\taccuracy\tchecked\t")
+	for _, field := range fields {
+		if s := result.Fields[field]; s.Checked > 0 {
+			fmt.Fprintf(w, "%s\t%.2f\t%d\t\n", field, s.Accuracy, s.Checked)
+		}
+	}
+	w.Flush()
+}
+
+func (c *counts) finish() {
+	c.Precision = ratio(c.Matched, c.Read)
+	c.Recall = ratio(c.Matched, c.Expected)
+}
+
+func (s *score) add(correct bool) {
+	s.Checked++
+	if correct {
+		s.Correct++
+	}
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// quantity reads the quantity of an item read, a number from the model or
+// the default ReadReceipt filled in.
+func quantity(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func text(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// digits compares prices as printed, "Rp 18.500" and "18500" are the same.
+func digits(price string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, price)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"Go-Starter-Template/internal/utils/pagination"
+	"errors"
+	"time"
+)
+
+var (
+	MessageSuccessGetUsers           = "users retrieved successfully"
+	MessageSuccessGetUser            = "user retrieved successfully"
+	MessageSuccessSuspendUser        = "user suspended"
+	MessageSuccessUnsuspendUser      = "user unsuspended"
+	MessageSuccessVerifyUser         = "user verified"
+	MessageSuccessUpdateSubscription = "subscription updated"
+	MessageSuccessGetPaymentEvents   = "payment events retrieved successfully"
+	MessageSuccessGetWasteStatistics = "waste statistics retrieved successfully"
+	MessageFailedGetUsers            = "failed to retrieve users"
+	MessageFailedGetUser             = "failed to retrieve user"
+	MessageFailedSuspendUser         = "failed to suspend user"
+	MessageFailedUnsuspendUser       = "failed to unsuspend user"
+	MessageFailedVerifyUser          = "failed to verify user"
+	MessageFailedUpdateSubscription  = "failed to update subscription"
+	MessageFailedGetPaymentEvents    = "failed to retrieve payment events"
+	MessageFailedGetWasteStatistics  = "failed to retrieve waste statistics"
+
+	ErrCannotSuspendSelf    = errors.New("you cannot suspend your own account")
+	ErrUserAlreadySuspended = errors.New("user is already suspended")
+	ErrUserNotSuspended     = errors.New("user is not suspended")
+)
+
+type (
+	AdminUserResponse struct {
+		ID              string     `json:"id"`
+		Name            string     `json:"name"`
+		Username        string     `json:"username"`
+		Email           string     `json:"email"`
+		Contact         string     `json:"contact"`
+		Role            string     `json:"role"`
+		Verified        bool       `json:"verified"`
+		Subscription    bool       `json:"subscription"`
+		MFAEnabled      bool       `json:"mfa_enabled"`
+		SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+		SuspendedReason string     `json:"suspended_reason,omitempty"`
+		CreatedAt       time.Time  `json:"created_at"`
+	}
+
+	AdminUserListResponse struct {
+		Items []AdminUserResponse `json:"items"`
+		Meta  pagination.Meta     `json:"meta"`
+	}
+
+	SuspendUserRequest struct {
+		Reason string `json:"reason" validate:"required,max=500"`
+	}
+
+	UpdateSubscriptionRequest struct {
+		Subscribe *bool `json:"subscribe" validate:"required"`
+	}
+
+	AdminTransactionResponse struct {
+		ID               string     `json:"id"`
+		UserID           string     `json:"user_id,omitempty"`
+		OrderID          string     `json:"order_id"`
+		Status           string     `json:"status"`
+		Amount           int64      `json:"amount"`
+		RefundedAmount   int64      `json:"refunded_amount"`
+		Gateway          string     `json:"gateway"`
+		GatewayReference string     `json:"gateway_reference"`
+		PaidAt           *time.Time `json:"paid_at,omitempty"`
+		CreatedAt        time.Time  `json:"created_at"`
+	}
+
+	AdminTransactionListResponse struct {
+		Items []AdminTransactionResponse `json:"items"`
+		Meta  pagination.Meta            `json:"meta"`
+	}
+
+	PaymentEventResponse struct {
+		ID         string    `json:"id"`
+		ActorID    string    `json:"actor_id,omitempty"`
+		Source     string    `json:"source"`
+		Type       string    `json:"type"`
+		FromStatus string    `json:"from_status"`
+		ToStatus   string    `json:"to_status"`
+		Amount     int64     `json:"amount"`
+		Reference  string    `json:"reference,omitempty"`
+		Note       string    `json:"note,omitempty"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	// WasteStatistics covers every household on the platform, wasted means
+	// the item ended up Expired or Damaged.
+	WasteStatistics struct {
+		TotalUsers     int64             `json:"total_users"`
+		ActiveUsers    int64             `json:"active_users"` // users with at least one food item
+		TotalItems     int64             `json:"total_items"`
+		ItemsByStatus  map[string]int64  `json:"items_by_status"`
+		WastedItems    int64             `json:"wasted_items"`
+		WasteRate      float64           `json:"waste_rate"`
+		TopWastedItems []WastedItemCount `json:"top_wasted_items"`
+	}
+
+	WastedItemCount struct {
+		Name  string `json:"name"`
+		Count int64  `json:"count"`
+	}
+)
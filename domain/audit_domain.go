@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"Go-Starter-Template/internal/utils/pagination"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditUserUpdated             = "user.updated"
+	AuditUserEmailChanged        = "user.email_changed"
+	AuditUserPasswordReset       = "user.password_reset"
+	AuditUserPasswordChanged     = "user.password_changed"
+	AuditUserMFAEnabled          = "user.mfa_enabled"
+	AuditUserMFADisabled         = "user.mfa_disabled"
+	AuditUserSuspended           = "user.suspended"
+	AuditUserUnsuspended         = "user.unsuspended"
+	AuditUserVerified            = "user.verified"
+	AuditUserSubscriptionUpdated = "user.subscription_updated"
+	AuditUserRoleAssigned        = "user.role_assigned"
+	AuditUserRoleRemoved         = "user.role_removed"
+	AuditUserDataExported        = "user.data_exported"
+	AuditUserDeletionRequested   = "user.deletion_requested"
+	AuditUserDeletionCancelled   = "user.deletion_cancelled"
+	AuditUserDeleted             = "user.deleted"
+	AuditRolePermissionsUpdated  = "role.permissions_updated"
+	AuditMFAPolicyUpdated        = "mfa_policy.updated"
+	AuditFoodItemDeleted         = "food_item.deleted"
+	AuditFoodItemRestored        = "food_item.restored"
+	AuditFoodItemPurged          = "food_item.purged"
+	AuditTransactionPrefix       = "transaction."
+
+	AuditTargetUser        = "user"
+	AuditTargetRole        = "role"
+	AuditTargetMFAPolicy   = "mfa_policy"
+	AuditTargetFoodItem    = "food_item"
+	AuditTargetTransaction = "transaction"
+)
+
+var (
+	MessageSuccessGetAuditLogs = "audit logs retrieved successfully"
+	MessageFailedGetAuditLogs  = "failed to retrieve audit logs"
+)
+
+type (
+	// AuditEntry is what services record. Before and After are snapshots,
+	// only the fields that differ between them are stored. The actor and
+	// request details are taken from the context.
+	AuditEntry struct {
+		Action     string
+		TargetType string
+		TargetID   string
+		Before     any
+		After      any
+	}
+
+	AuditLogResponse struct {
+		ID         int64           `json:"id"`
+		ActorID    string          `json:"actor_id,omitempty"`
+		ActorRole  string          `json:"actor_role,omitempty"`
+		Action     string          `json:"action"`
+		TargetType string          `json:"target_type"`
+		TargetID   string          `json:"target_id"`
+		Before     json.RawMessage `json:"before,omitempty"`
+		After      json.RawMessage `json:"after,omitempty"`
+		IPAddress  string          `json:"ip_address,omitempty"`
+		UserAgent  string          `json:"user_agent,omitempty"`
+		RequestID  string          `json:"request_id,omitempty"`
+		CreatedAt  time.Time       `json:"created_at"`
+		Hash       string          `json:"hash"`
+	}
+
+	AuditLogListResponse struct {
+		Items []AuditLogResponse `json:"items"`
+		Meta  pagination.Meta    `json:"meta"`
+	}
+
+	// AuditVerifyResult reports the first row where the chain breaks.
+	// HeadHash should be kept somewhere else and passed back as the anchor
+	// later, it is the only way to notice rows removed from the end.
+	AuditVerifyResult struct {
+		Checked     int64  `json:"checked"`
+		HeadID      int64  `json:"head_id"`
+		HeadHash    string `json:"head_hash"`
+		Valid       bool   `json:"valid"`
+		BrokenAt    int64  `json:"broken_at,omitempty"`
+		Reason      string `json:"reason,omitempty"`
+		AnchorFound bool   `json:"anchor_found,omitempty"`
+	}
+)
@@ -6,8 +6,10 @@ import (
 )
 
 const (
-	RoleUser = "user"
-	//ROLE_ADMIN  = "admin"
+	RoleUser    = "user"
+	RolePremium = "premium"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
 	//ROLE_MENTOR = "mentor"
 )
 
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DataExportStatusPending    = "pending"
+	DataExportStatusProcessing = "processing"
+	DataExportStatusReady      = "ready"
+	DataExportStatusFailed     = "failed"
+	DataExportStatusExpired    = "expired"
+)
+
+var (
+	MessageSuccessRequestDataExport = "data export requested, we will email you a link when it is ready"
+	MessageSuccessGetDataExports    = "data exports retrieved successfully"
+	MessageSuccessDownloadExport    = "data export link created"
+	MessageFailedRequestDataExport  = "failed to request data export"
+	MessageFailedGetDataExports     = "failed to retrieve data exports"
+	MessageFailedDownloadExport     = "failed to create data export link"
+
+	ErrDataExportInProgress = errors.New("a data export is already being prepared")
+	ErrDataExportNotFound   = errors.New("data export not found")
+	ErrDataExportNotReady   = errors.New("data export is not ready or has expired")
+)
+
+type (
+	DataExportResponse struct {
+		ID          string     `json:"id"`
+		Status      string     `json:"status"`
+		SizeBytes   int64      `json:"size_bytes,omitempty"`
+		CreatedAt   time.Time  `json:"created_at"`
+		CompletedAt *time.Time `json:"completed_at,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	}
+
+	DataExportLinkResponse struct {
+		URL       string    `json:"url"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
@@ -6,6 +6,14 @@ import (
 	"time"
 )
 
+// Kinds of entities.ReceiptCorrection.
+const (
+	ReceiptCorrectionNameChanged     = "name_changed"
+	ReceiptCorrectionQuantityChanged = "quantity_changed"
+	ReceiptCorrectionItemRemoved     = "item_removed"
+	ReceiptCorrectionItemAdded       = "item_added"
+)
+
 var (
 	MessageSuccessAddFoodItem       = "food item added successfully"
 	MessageSuccessUpdateFoodItem    = "food item updated successfully"
@@ -15,6 +23,9 @@ var (
 	MessageSuccessSaveScannedItems  = "scanned items saved successfully"
 	MessageSuccessMarkAsDamaged     = "food item marked as damaged"
 	MessageSuccessGetDashboardStats = "dashboard statistics retrieved successfully"
+	MessageSuccessGetTrash          = "trash retrieved successfully"
+	MessageSuccessRestoreFoodItem   = "food item restored successfully"
+	MessageSuccessPurgeFoodItem     = "food item permanently deleted"
 
 	MessageFailedAddFoodItem       = "failed to add food item"
 	MessageFailedUpdateFoodItem    = "failed to update food item"
@@ -26,6 +37,9 @@ var (
 	MessageFailedMarkAsDamaged     = "failed to mark food item as damaged"
 	MessageFailedGetDashboardStats = "failed to retrieve dashboard statistics"
 	MessageFailedDetectFoodAge     = "failed to detect food age from image"
+	MessageFailedGetTrash          = "failed to retrieve trash"
+	MessageFailedRestoreFoodItem   = "failed to restore food item"
+	MessageFailedPurgeFoodItem     = "failed to permanently delete food item"
 
 	ErrFoodItemNotFound        = errors.New("food item not found")
 	ErrReceiptProcessingFailed = errors.New("receipt processing failed")
@@ -35,6 +49,7 @@ var (
 	ErrInvalidReceiptScan      = errors.New("invalid receipt scan ID")
 	ErrUnauthorizedAccess      = errors.New("unauthorized access to food item")
 	ErrGeminiProcessingFailed  = errors.New("gemini processing failed")
+	ErrDuplicateReceipt        = errors.New("this receipt was already uploaded")
 )
 
 type (
@@ -64,20 +79,50 @@ type (
 		IsPackaged  bool   `json:"is_packaged"`
 	}
 
+	// UploadFoodImageRequest takes the image as a file or as the key of a
+	// presigned upload, see CreateUploadRequest.
 	UploadFoodImageRequest struct {
 		FoodItemID string                `json:"food_id" form:"food_id" validate:"required,uuid"`
-		Image      *multipart.FileHeader `json:"image" form:"image" validate:"required"`
+		Image      *multipart.FileHeader `json:"image" form:"image" validate:"required_without=ObjectKey"`
+		ObjectKey  string                `json:"object_key" form:"object_key"`
 	}
 
+	// UploadReceiptRequest takes one receipt as a photo, several photos of
+	// a long receipt in order, or a PDF, as files or presigned upload keys.
 	UploadReceiptRequest struct {
-		ReceiptImage *multipart.FileHeader `json:"receipt_image" form:"receipt_image" validate:"required"`
+		ReceiptImage  *multipart.FileHeader   `json:"receipt_image" form:"receipt_image"`
+		ReceiptImages []*multipart.FileHeader `json:"receipt_images" form:"receipt_images"`
+		ObjectKey     string                  `json:"object_key" form:"object_key"`
+		ObjectKeys    []string                `json:"object_keys" form:"object_keys"`
+		// AllowDuplicate scans a receipt even though the same file was
+		// already uploaded.
+		AllowDuplicate bool `json:"allow_duplicate" form:"allow_duplicate"`
 	}
 
 	// In domain/food_item.go
 	UploadReceiptResponse struct {
-		ScanID string                   `json:"scan_id"`
-		Status string                   `json:"status"`
-		Items  []map[string]interface{} `json:"items"`
+		ScanID  string                   `json:"scan_id"`
+		Status  string                   `json:"status"`
+		Receipt ReceiptHeader            `json:"receipt"`
+		Items   []map[string]interface{} `json:"items"`
+		// DuplicateOf is the earlier scan this receipt looks like, with
+		// ErrDuplicateReceipt the one it is.
+		DuplicateOf string `json:"duplicate_of,omitempty"`
+	}
+
+	// ReceiptHeader is what a receipt says about the purchase as a whole,
+	// amounts it does not show are nil. NeedsReview is set when ItemsTotal,
+	// the sum of the lines read, does not add up to the printed amounts.
+	ReceiptHeader struct {
+		MerchantName   string     `json:"merchant_name"`
+		MerchantBranch string     `json:"merchant_branch"`
+		PurchasedAt    *time.Time `json:"purchased_at"`
+		Subtotal       *float64   `json:"subtotal"`
+		Tax            *float64   `json:"tax"`
+		Discount       *float64   `json:"discount"`
+		Total          *float64   `json:"total"`
+		ItemsTotal     *float64   `json:"items_total"`
+		NeedsReview    bool       `json:"needs_review"`
 	}
 
 	ScannedItemRequest struct {
@@ -90,6 +135,9 @@ type (
 		EstimatedAge int     `json:"estimated_age,omitempty"`
 		Category     string  `json:"category,omitempty"`
 		Confidence   float64 `json:"confidence,omitempty"`
+		// OcrIndex is the position of the scanned item this one was edited
+		// from, nil for an item the user added.
+		OcrIndex *int `json:"ocr_index,omitempty"`
 	}
 
 	SaveScannedItemsRequest struct {
@@ -107,15 +155,24 @@ type (
 	}
 
 	FoodItemResponse struct {
-		ID          string    `json:"id"`
-		Name        string    `json:"name"`
-		Quantity    int       `json:"quantity"`
-		UnitMeasure string    `json:"unit_measure"`
-		ExpiryDate  time.Time `json:"expiry_date"`
-		IsPackaged  bool      `json:"is_packaged"`
-		Status      string    `json:"status"`
-		ImageURL    string    `json:"image_url,omitempty"`
-		CreatedAt   time.Time `json:"created_at"`
+		ID          string            `json:"id"`
+		Name        string            `json:"name"`
+		Quantity    int               `json:"quantity"`
+		UnitMeasure string            `json:"unit_measure"`
+		ExpiryDate  time.Time         `json:"expiry_date"`
+		IsPackaged  bool              `json:"is_packaged"`
+		Status      string            `json:"status"`
+		ImageURL    string            `json:"image_url,omitempty"`
+		Thumbnails  map[string]string `json:"thumbnails,omitempty"` // "sm" and "md" links
+		CreatedAt   time.Time         `json:"created_at"`
+	}
+
+	// TrashedFoodItemResponse is an item in the trash, PurgeAt is when it
+	// is deleted for good.
+	TrashedFoodItemResponse struct {
+		FoodItemResponse
+		DeletedAt time.Time `json:"deleted_at"`
+		PurgeAt   time.Time `json:"purge_at"`
 	}
 
 	MarkAsDamagedRequest struct {
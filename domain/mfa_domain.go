@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+)
+
+// Authentication method references (RFC 8176) recorded on sessions.
+const (
+	AMRPassword  = "pwd"
+	AMROTP       = "otp"
+	AMRFederated = "fed"
+)
+
+var (
+	MessageSuccessMFAEnroll        = "scan the qr code and confirm with a code"
+	MessageSuccessMFAEnable        = "two factor authentication enabled"
+	MessageSuccessMFADisable       = "two factor authentication disabled"
+	MessageSuccessMFAVerify        = "two factor verification success"
+	MessageSuccessMFARecoveryCodes = "recovery codes regenerated"
+	MessageSuccessGetMFAPolicies   = "mfa policies retrieved successfully"
+	MessageSuccessUpdateMFAPolicy  = "mfa policy updated successfully"
+	MessageFailedMFAEnroll         = "failed to enroll two factor authentication"
+	MessageFailedMFAEnable         = "failed to enable two factor authentication"
+	MessageFailedMFADisable        = "failed to disable two factor authentication"
+	MessageFailedMFAVerify         = "two factor verification failed"
+	MessageFailedMFARecoveryCodes  = "failed to regenerate recovery codes"
+	MessageFailedGetMFAPolicies    = "failed to retrieve mfa policies"
+	MessageFailedUpdateMFAPolicy   = "failed to update mfa policy"
+
+	ErrMFAAlreadyEnabled = errors.New("two factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two factor authentication is not enabled")
+	ErrMFANotEnrolled    = errors.New("start enrollment before enabling two factor authentication")
+	ErrMFACodeInvalid    = errors.New("two factor code invalid")
+	ErrMFARequired       = errors.New("two factor authentication is required for this role")
+	ErrUnknownRole       = errors.New("unknown role")
+)
+
+type (
+	MFAEnrollResponse struct {
+		Secret     string `json:"secret"`
+		OTPAuthURL string `json:"otpauth_url"`
+		QRCode     string `json:"qr_code"` // base64 encoded PNG
+	}
+
+	// MFACodeRequest accepts either a 6 digit TOTP code or a recovery code.
+	MFACodeRequest struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	MFAVerifyRequest struct {
+		MFAToken   string `json:"mfa_token" validate:"required"`
+		Code       string `json:"code" validate:"required"`
+		DeviceName string `json:"device_name" validate:"omitempty,max=100"`
+	}
+
+	MFARecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	MFAPolicyRequest struct {
+		Required bool `json:"required"`
+	}
+
+	MFAPolicyResponse struct {
+		Role     string `json:"role"`
+		Required bool   `json:"required"`
+	}
+)
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	MessageSuccessOAuthLogin     = "authorization url created"
+	MessageSuccessOAuthCallback  = "oauth sign in success"
+	MessageSuccessGetIdentities  = "identities retrieved successfully"
+	MessageSuccessLinkIdentity   = "identity linked successfully"
+	MessageSuccessUnlinkIdentity = "identity unlinked successfully"
+	MessageFailedOAuthLogin      = "failed to start oauth sign in"
+	MessageFailedOAuthCallback   = "oauth sign in failed"
+	MessageFailedGetIdentities   = "failed to retrieve identities"
+	MessageFailedLinkIdentity    = "failed to link identity"
+	MessageFailedUnlinkIdentity  = "failed to unlink identity"
+
+	ErrOAuthStateInvalid     = errors.New("oauth state invalid or expired")
+	ErrOAuthEmailNotVerified = errors.New("provider email is not verified")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to another account")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("cannot unlink the only way to sign in, set a password first")
+)
+
+type (
+	OAuthCallbackRequest struct {
+		Code  string `query:"code" validate:"required"`
+		State string `query:"state" validate:"required"`
+	}
+
+	OAuthAuthorizationResponse struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+
+	// OAuthCallbackResponse carries the session tokens after a sign in, or the
+	// linked identity when the flow was started from the profile.
+	OAuthCallbackResponse struct {
+		*UserLoginResponse
+		Identity *UserIdentityResponse `json:"identity,omitempty"`
+	}
+
+	UserIdentityResponse struct {
+		Provider  string    `json:"provider"`
+		Email     string    `json:"email"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
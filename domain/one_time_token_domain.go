@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailChange       = "email_change"
+	TokenPurposeHouseholdInvite   = "household_invite"
+	TokenPurposeAccountUnlock     = "account_unlock"
+)
+
+type (
+	// OneTimeTokenRequest describes a token to issue. Issuing a token for a
+	// user revokes the unused ones of the same purpose, so only the newest
+	// link works.
+	OneTimeTokenRequest struct {
+		Purpose string
+		UserID  *uuid.UUID
+		Subject string
+		Payload any
+		TTL     time.Duration
+	}
+)
@@ -0,0 +1,81 @@
+package domain
+
+import "errors"
+
+const (
+	TransactionStatusPending  = "pending"
+	TransactionStatusPaid     = "paid"
+	TransactionStatusFraud    = "fraud"
+	TransactionStatusFailed   = "failed"
+	TransactionStatusRefunded = "refunded"
+
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusCancelled         = "cancelled"
+
+	PaymentEventSourceWebhook   = "webhook"
+	PaymentEventSourceReconcile = "reconcile"
+	PaymentEventSourceAdmin     = "admin"
+	PaymentEventSourceUser      = "user"
+
+	PaymentEventStatusChanged = "status_changed"
+	PaymentEventRefunded      = "refunded"
+	PaymentEventCancelled     = "cancelled"
+)
+
+var (
+	MessageSuccessWebhook           = "Webhook processed successfully"
+	MessageSuccessCreateTransaction = "Transaction processed successfully"
+
+	MessageSuccessRefundTransaction = "transaction refunded successfully"
+	MessageSuccessCancelTransaction = "transaction cancelled successfully"
+
+	MessageFailedCreateTransaction = "failed to create transaction"
+	MessageFailedRefundTransaction = "failed to refund transaction"
+	MessageFailedCancelTransaction = "failed to cancel transaction"
+
+	ErrCreateTransactionFailed  = errors.New("create transaction failed")
+	ErrInvalidSignature         = errors.New("Invalid signature")
+	ErrTransactionNotFound      = errors.New("Transaction not found")
+	ErrTransactionNotRefundable = errors.New("only paid transactions can be refunded")
+	ErrTransactionNotPending    = errors.New("only pending transactions can be cancelled")
+	ErrRefundAmountExceeded     = errors.New("refund amount exceeds the refundable amount")
+	ErrRefundFailed             = errors.New("refund failed")
+	ErrRefundConflict           = errors.New("the transaction changed during the refund, try again")
+	ErrCancelFailed             = errors.New("cancel failed")
+)
+
+type (
+	PaymentRequest struct {
+		Amount  int64  `json:"amount" validate:"required"`
+		Email   string `json:"email" validate:"required"`
+		Gateway string `json:"gateway" validate:"omitempty,oneof=midtrans xendit"`
+	}
+
+	PaymentInvoiceUrl struct {
+		Invoice string `json:"invoice"`
+		Gateway string `json:"gateway"`
+	}
+
+	RefundTransactionRequest struct {
+		// Amount of zero refunds whatever is left of the transaction.
+		Amount int64  `json:"amount" validate:"omitempty,min=1"`
+		Reason string `json:"reason" validate:"required"`
+	}
+
+	RefundTransactionResponse struct {
+		TransactionID  string `json:"transaction_id"`
+		Status         string `json:"status"`
+		RefundedAmount int64  `json:"refunded_amount"`
+		RefundID       string `json:"refund_id"`
+	}
+
+	CancelTransactionResponse struct {
+		TransactionID string `json:"transaction_id"`
+		Status        string `json:"status"`
+	}
+
+	PaymentWebhookResponse struct {
+		TransactionStatus string `json:"transaction_status"`
+		OrderID           string `json:"order_id"`
+	}
+)
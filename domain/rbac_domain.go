@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+)
+
+// Permissions are "<resource>:<action>[:<scope>]". A granted permission may
+// end in "*" to cover everything below it, "*" alone grants everything.
+const (
+	PermAll = "*"
+
+	PermFoodRead      = "food:read:own"
+	PermFoodWrite     = "food:write:own"
+	PermFoodDelete    = "food:delete:own"
+	PermFoodDeleteAny = "food:delete:any"
+
+	PermUserRead         = "user:read:any"
+	PermUserSuspend      = "user:suspend:any"
+	PermUserVerify       = "user:verify:any"
+	PermSubscriptionEdit = "subscription:write:any"
+
+	PermTransactionRead   = "transaction:read:any"
+	PermTransactionRefund = "transaction:refund:any"
+
+	PermStatisticsRead = "statistics:read"
+	PermMFAPolicyEdit  = "mfa_policy:write"
+	PermRBACManage     = "rbac:manage"
+	PermAuditRead      = "audit:read"
+)
+
+var (
+	// DefaultRoles are created by the seeder. Every user implicitly holds the
+	// role in User.Role, and RolePremium while subscribed.
+	DefaultRoles = []string{RoleUser, RolePremium, RoleSupport, RoleAdmin}
+
+	MessageSuccessGetRoles       = "roles retrieved successfully"
+	MessageSuccessGetPermissions = "permissions retrieved successfully"
+	MessageSuccessUpdateRole     = "role updated successfully"
+	MessageSuccessAssignRole     = "role assigned successfully"
+	MessageSuccessRemoveRole     = "role removed successfully"
+	MessageSuccessGetUserRoles   = "user roles retrieved successfully"
+	MessageFailedGetRoles        = "failed to retrieve roles"
+	MessageFailedGetPermissions  = "failed to retrieve permissions"
+	MessageFailedUpdateRole      = "failed to update role"
+	MessageFailedAssignRole      = "failed to assign role"
+	MessageFailedRemoveRole      = "failed to remove role"
+	MessageFailedGetUserRoles    = "failed to retrieve user roles"
+
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrPermissionNotFound = errors.New("permission not found")
+	ErrMissingPermission  = errors.New("missing permission")
+	ErrImplicitRole       = errors.New("role is implied by the account and cannot be removed")
+)
+
+type (
+	PermissionResponse struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	RoleResponse struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	UpdateRolePermissionsRequest struct {
+		Permissions []string `json:"permissions" validate:"required"`
+	}
+
+	// UserRolesResponse separates roles assigned by an admin from the ones
+	// implied by the account, which change with User.Role and the
+	// subscription.
+	UserRolesResponse struct {
+		Assigned    []string `json:"assigned"`
+		Implicit    []string `json:"implicit"`
+		Permissions []string `json:"permissions"`
+	}
+)
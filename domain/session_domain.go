@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	MessageSuccessRefreshToken  = "refresh token success"
+	MessageSuccessLogout        = "logout success"
+	MessageSuccessGetSessions   = "sessions retrieved successfully"
+	MessageSuccessRevokeSession = "session revoked successfully"
+
+	MessageFailedRefreshToken  = "failed refresh token"
+	MessageFailedLogout        = "failed logout"
+	MessageFailedGetSessions   = "failed to retrieve sessions"
+	MessageFailedRevokeSession = "failed to revoke session"
+
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrSessionExpired      = errors.New("session expired")
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+	ErrAccountSuspended    = errors.New("account suspended")
+)
+
+type (
+	// SessionClient describes the device a session was started from.
+	SessionClient struct {
+		DeviceName string
+		IPAddress  string
+		UserAgent  string
+	}
+
+	RefreshTokenRequest struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	SessionResponse struct {
+		ID         string    `json:"id"`
+		DeviceName string    `json:"device_name"`
+		IPAddress  string    `json:"ip_address"`
+		UserAgent  string    `json:"user_agent"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		CreatedAt  time.Time `json:"created_at"`
+		Current    bool      `json:"current"`
+	}
+)
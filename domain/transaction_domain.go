@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"Go-Starter-Template/internal/utils/pagination"
+	"errors"
+	"time"
+)
+
+var (
+	MessageSuccessGetTransactions      = "transactions retrieved successfully"
+	MessageSuccessGetTransactionDetail = "transaction retrieved successfully"
+
+	MessageFailedGetTransactions      = "failed to retrieve transactions"
+	MessageFailedGetTransactionDetail = "failed to retrieve transaction"
+	MessageFailedGenerateInvoice      = "failed to generate invoice"
+	MessageFailedGenerateReceipt      = "failed to generate receipt"
+
+	ErrTransactionNotPaid = errors.New("transaction has not been paid")
+)
+
+type (
+	TransactionResponse struct {
+		ID         string     `json:"id"`
+		OrderID    string     `json:"order_id"`
+		Status     string     `json:"status"`
+		Amount     int64      `json:"amount"`
+		PaymentURL string     `json:"payment_url"`
+		ReceiptURL string     `json:"receipt_url,omitempty"`
+		PaidAt     *time.Time `json:"paid_at,omitempty"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	TransactionListResponse struct {
+		Items []TransactionResponse `json:"items"`
+		Meta  pagination.Meta       `json:"meta"`
+	}
+
+	TransactionDocument struct {
+		Filename string
+		Content  []byte
+	}
+)
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	UploadPurposeFoodImage      = "food_image"
+	UploadPurposeReceipt        = "receipt"
+	UploadPurposeProfilePicture = "profile_picture"
+)
+
+var (
+	MessageSuccessCreateUpload = "upload URL created successfully"
+	MessageFailedCreateUpload  = "failed to create upload URL"
+
+	ErrUploadPurposeInvalid = errors.New("invalid upload purpose")
+	ErrUploadTypeNotAllowed = errors.New("file type is not allowed for this upload")
+	ErrUploadTooLarge       = errors.New("file is too large")
+	ErrUploadNotFound       = errors.New("upload not found, it may have expired")
+	ErrUploadTooManyPages   = errors.New("too many pages")
+)
+
+type (
+	CreateUploadRequest struct {
+		Purpose     string `json:"purpose" validate:"required,oneof=food_image receipt profile_picture"`
+		ContentType string `json:"content_type" validate:"required"`
+		Size        int64  `json:"size" validate:"required,min=1"`
+	}
+
+	// CreateUploadResponse tells the client where to PUT the file. The
+	// request has to carry Headers and a body of exactly the size asked
+	// for, then ObjectKey is passed to the endpoint the upload was for.
+	CreateUploadResponse struct {
+		ObjectKey string            `json:"object_key"`
+		UploadURL string            `json:"upload_url"`
+		Method    string            `json:"method"`
+		Headers   map[string]string `json:"headers"`
+		ExpiresAt time.Time         `json:"expires_at"`
+	}
+)
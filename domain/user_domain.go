@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"mime/multipart"
+	"time"
 )
 
 var (
@@ -14,6 +15,11 @@ var (
 	MessageSuccessUpdateUser           = "update user success"
 	MessageSuccessSendEmail            = "send email success"
 	MessageSuccessUpdatePassword       = "update user password"
+	MessageSuccessChangePassword       = "password changed"
+	MessageSuccessUnlockAccount        = "account unlocked"
+	MessageSuccessConfirmEmailChange   = "email changed"
+	MessageSuccessRequestDeletion      = "account scheduled for deletion"
+	MessageSuccessCancelDeletion       = "account deletion cancelled"
 
 	MessageFailedBodyRequest    = "body request failed"
 	MessageFailedRegister       = "register failed"
@@ -21,6 +27,11 @@ var (
 	MessageFailedUpdateUser     = "failed update user"
 	MessageFailedSendEmail      = "failed send email"
 	MessageFailedUpdatePassword = "failed update password"
+	MessageFailedUnlockAccount  = "failed unlock account"
+	MessageFailedConfirmEmail   = "failed change email"
+	MessageFailedLogin          = "login failed"
+	MessageFailedDeleteAccount  = "failed delete account"
+	MessageFailedTooManyRequest = "too many requests, try again later"
 
 	ErrAccountAlreadyVerified = errors.New("account already verified")
 	ErrEmailAlreadyExists     = errors.New("email already exists")
@@ -33,6 +44,12 @@ var (
 	ErrRegisterUserFailed     = errors.New("register user failed")
 	ErrTokenInvalid           = errors.New("token invalid")
 	ErrTokenExpired           = errors.New("token expired")
+	ErrAccountLocked          = errors.New("account temporarily locked after too many failed logins, check your email to unlock it")
+	ErrTooManyLoginAttempts   = errors.New("too many failed logins from this address, try again later")
+	ErrCurrentPasswordInvalid = errors.New("current password is incorrect")
+	ErrPasswordReused         = errors.New("password was used recently, choose a different one")
+	ErrDeletionScheduled      = errors.New("account is already scheduled for deletion")
+	ErrDeletionNotScheduled   = errors.New("account is not scheduled for deletion")
 )
 
 type (
@@ -40,7 +57,7 @@ type (
 		Name     string `json:"name" validate:"required"`
 		Username string `json:"username" validate:"required,min=3"`
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		Password string `json:"password" validate:"required"`
 		Contact  string `json:"contact" validate:"required"`
 	}
 
@@ -50,13 +67,20 @@ type (
 	}
 
 	UserLoginRequest struct {
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=8"`
+		Email      string `json:"email" validate:"required,email"`
+		Password   string `json:"password" validate:"required,min=8"`
+		DeviceName string `json:"device_name" validate:"omitempty,max=100"`
 	}
 
+	// UserLoginResponse either holds the session tokens, or when the account
+	// has two factor authentication only MFARequired and MFAToken, which is
+	// exchanged at /auth/mfa/verify.
 	UserLoginResponse struct {
-		Token string `json:"token"`
-		Role  string `json:"role"`
+		Token        string `json:"token,omitempty"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		Role         string `json:"role,omitempty"`
+		MFARequired  bool   `json:"mfa_required,omitempty"`
+		MFAToken     string `json:"mfa_token,omitempty"`
 	}
 
 	SendVerifyEmailRequest struct {
@@ -79,9 +103,13 @@ type (
 		Contact        string `json:"contact"`
 		ProfilePicture string `json:"profile_picture"`
 		Subscription   bool   `json:"subscription"`
-		ActivePoint    int    `json:"active_point"`
-		LevelPoint     int    `json:"level_point"`
-		Rank           string `json:"rank"`
+		MFAEnabled     bool   `json:"mfa_enabled"`
+		// DeletionScheduledAt is when the account will be erased, unless the
+		// deletion is cancelled before then.
+		DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+		ActivePoint         int        `json:"active_point"`
+		LevelPoint          int        `json:"level_point"`
+		Rank                string     `json:"rank"`
 	}
 
 	UpdateUserRequest struct {
@@ -90,6 +118,8 @@ type (
 		Email          string                `json:"email" validate:"omitempty,email"`
 		Contact        string                `json:"contact" validate:"omitempty"`
 		ProfilePicture *multipart.FileHeader `json:"profile_picture" validate:"omitempty"`
+		// ProfilePictureKey is a presigned upload used instead of ProfilePicture.
+		ProfilePictureKey string `json:"profile_picture_key" form:"profile_picture_key" validate:"omitempty"`
 	}
 
 	UpdateUserResponse struct {
@@ -98,6 +128,14 @@ type (
 		Email          string `json:"email"`
 		Contact        string `json:"contact"`
 		ProfilePicture string `json:"profile_picture"`
+		// PendingEmail is set when the request asked for a new email, it only
+		// replaces Email once confirmed from that inbox.
+		PendingEmail string `json:"pending_email,omitempty"`
+	}
+
+	// EmailChangePayload is stored with an email change token.
+	EmailChangePayload struct {
+		OldEmail string `json:"old_email"`
 	}
 
 	ForgetPasswordRequest struct {
@@ -105,6 +143,23 @@ type (
 	}
 
 	ResetPasswordRequest struct {
-		Password string `json:"password" validate:"required,min=8"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	// ChangePasswordRequest leaves CurrentPassword empty for accounts that
+	// were created through a provider and never had a password.
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+
+	// DeleteAccountRequest leaves Password empty for accounts that were
+	// created through a provider and never had a password.
+	DeleteAccountRequest struct {
+		Password string `json:"password"`
+	}
+
+	AccountDeletionResponse struct {
+		DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
 	}
 )
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditLog is append only. Hash covers every other column together with
+// PrevHash, the hash of the row before it, so editing or removing a row
+// breaks the chain from that point on.
+type AuditLog struct {
+	ID         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id,omitempty"`
+	ActorRole  string     `json:"actor_role,omitempty"`
+	Action     string     `gorm:"index" json:"action"`
+	TargetType string     `gorm:"index:idx_audit_logs_target" json:"target_type"`
+	TargetID   string     `gorm:"index:idx_audit_logs_target" json:"target_id"`
+	Before     string     `gorm:"type:text" json:"before,omitempty"` // JSON of the changed fields
+	After      string     `gorm:"type:text" json:"after,omitempty"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	RequestID  string     `gorm:"index" json:"request_id,omitempty"`
+	CreatedAt  time.Time  `gorm:"type:timestamp;index" json:"created_at"`
+	PrevHash   string     `json:"prev_hash"`
+	Hash       string     `gorm:"uniqueIndex" json:"hash"`
+}
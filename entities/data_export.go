@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// DataExport is one request for a copy of a user's personal data. The ZIP
+// lives in the bucket under ObjectKey until ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	Status      string     `gorm:"index" json:"status"` // "pending", "processing", "ready", "failed", "expired"
+	ObjectKey   string     `json:"-"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `gorm:"type:timestamp" json:"started_at,omitempty"`
+	CompletedAt *time.Time `gorm:"type:timestamp" json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `gorm:"type:timestamp;index" json:"expires_at,omitempty"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+	Timestamp
+}
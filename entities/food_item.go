@@ -15,6 +15,7 @@ type FoodItem struct {
 	IsPackaged    bool      `json:"is_packaged"`
 	Status        string    `json:"status"` // "Safe", "Warning", "Expired", "Damaged"
 	ImageURL      string    `json:"image_url,omitempty"`
+	HasThumbnails bool      `json:"has_thumbnails"`
 	AddedManually bool      `json:"added_manually"`
 	ReceiptScanID *string   `json:"receipt_scan_id,omitempty"`
 
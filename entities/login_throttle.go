@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+)
+
+// LoginThrottle counts recent failed logins for one key, either
+// "account:<email>" or "ip:<address>".
+type LoginThrottle struct {
+	Key           string     `gorm:"primary_key" json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `gorm:"type:timestamp" json:"last_failure_at"`
+	LockedUntil   *time.Time `gorm:"type:timestamp" json:"locked_until,omitempty"`
+}
+
+// RateLimitEntry is a fiber.Storage row shared by every API instance.
+type RateLimitEntry struct {
+	Key       string    `gorm:"primary_key" json:"key"`
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `gorm:"type:timestamp;index" json:"expires_at"`
+}
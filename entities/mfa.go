@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RecoveryCode is a single use fallback for a lost authenticator. Only the
+// hash is stored, the codes are shown to the user once.
+type RecoveryCode struct {
+	ID       uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	CodeHash string     `gorm:"index" json:"-"`
+	UsedAt   *time.Time `gorm:"type:timestamp" json:"used_at,omitempty"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+	Timestamp
+}
+
+// MFAPolicy marks a role whose members need a second factor on the session
+// before they can use routes guarded by RequireMFA.
+type MFAPolicy struct {
+	Role     string `gorm:"primary_key" json:"role"`
+	Required bool   `gorm:"default:false" json:"required"`
+
+	Timestamp
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OAuthState keeps the PKCE verifier and nonce of an authorization request
+// until the provider redirects back. It is deleted when the callback uses it.
+type OAuthState struct {
+	StateHash    string     `gorm:"primary_key" json:"-"`
+	Provider     string     `json:"provider"`
+	CodeVerifier string     `json:"-"`
+	Nonce        string     `json:"-"`
+	UserID       *uuid.UUID `gorm:"type:uuid" json:"user_id,omitempty"` // set when linking to a signed in user
+	ExpiresAt    time.Time  `gorm:"type:timestamp;index" json:"expires_at"`
+	CreatedAt    time.Time  `gorm:"type:timestamp" json:"created_at"`
+}
+
+func (OAuthState) TableName() string {
+	return "oauth_states"
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OneTimeToken backs every emailed link. Only the sha256 of the token is
+// stored and a token stops working once ConsumedAt is set.
+type OneTimeToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TokenHash  string     `gorm:"uniqueIndex" json:"-"`
+	Purpose    string     `gorm:"index" json:"purpose"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Subject    string     `json:"subject"`                  // the address the link was sent to
+	Payload    string     `gorm:"type:text" json:"payload"` // purpose specific JSON
+	ExpiresAt  time.Time  `gorm:"type:timestamp;index" json:"expires_at"`
+	ConsumedAt *time.Time `gorm:"type:timestamp" json:"consumed_at,omitempty"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+	Timestamp
+}
@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PasswordHistory keeps the hashes of passwords a user had before, so the
+// policy can refuse reusing one of the last few.
+type PasswordHistory struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `gorm:"type:timestamp;index" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+}
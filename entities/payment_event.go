@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PaymentEvent is an append only trail of every change made to a Transaction.
+type PaymentEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:uuid;index" json:"transaction_id"`
+	ActorID       *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Source        string     `json:"source"` // "webhook", "reconcile", "admin", "user"
+	Type          string     `json:"type"`   // "status_changed", "refunded", "cancelled"
+	FromStatus    string     `json:"from_status"`
+	ToStatus      string     `json:"to_status"`
+	Amount        int64      `json:"amount"`
+	Reference     string     `json:"reference,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `gorm:"type:timestamp" json:"created_at"`
+
+	Transaction *Transaction `gorm:"foreignKey:TransactionID" json:"-"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name        string       `gorm:"uniqueIndex" json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions,omitempty"`
+
+	Timestamp
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name        string    `gorm:"uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+
+	Timestamp
+}
@@ -0,0 +1,19 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// ReceiptCorrection is one change the user made to the items read off a
+// receipt before saving them, kept to measure how well receipts are read.
+type ReceiptCorrection struct {
+	ID                uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ReceiptScanID     uuid.UUID `gorm:"type:uuid;index" json:"receipt_scan_id"`
+	UserID            uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	Kind              string    `json:"kind"` // "name_changed", "quantity_changed", "item_removed", "item_added"
+	OriginalName      string    `json:"original_name,omitempty"`
+	CorrectedName     string    `json:"corrected_name,omitempty"`
+	OriginalQuantity  int       `json:"original_quantity,omitempty"`
+	CorrectedQuantity int       `json:"corrected_quantity,omitempty"`
+	Timestamp
+}
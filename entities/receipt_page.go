@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// ReceiptPage is one photo or PDF page of a receipt sent in several parts,
+// Number orders them from 1.
+type ReceiptPage struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ReceiptScanID uuid.UUID `gorm:"type:uuid;index" json:"receipt_scan_id"`
+	Number        int       `json:"number"`
+	ObjectKey     string    `json:"object_key"`
+	ContentType   string    `json:"content_type"`
+	HasThumbnails bool      `json:"has_thumbnails"`
+	Timestamp
+}
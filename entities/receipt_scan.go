@@ -2,16 +2,33 @@ package entities
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type ReceiptScan struct {
-	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID     uuid.UUID `json:"user_id"`
-	ImageURL   string    `json:"image_url"`
-	Status     string    `json:"status"` // "Pending", "Processed", "Failed"
-	OcrResults string    `json:"ocr_results,omitempty" gorm:"type:text"`
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	ImageURL      string    `json:"image_url"`
+	HasThumbnails bool      `json:"has_thumbnails"`
+	Status        string    `json:"status"` // "Pending", "Processed", "Failed"
+	OcrResults    string    `json:"ocr_results,omitempty" gorm:"type:text"`
 
-	User      *User       `gorm:"foreignKey:UserID"`
-	FoodItems []*FoodItem `gorm:"foreignKey:ReceiptScanID"`
+	MerchantName   string     `json:"merchant_name"`
+	MerchantBranch string     `json:"merchant_branch"`
+	PurchasedAt    *time.Time `json:"purchased_at"`
+	Subtotal       *float64   `json:"subtotal"`
+	Tax            *float64   `json:"tax"`
+	Discount       *float64   `json:"discount"`
+	Total          *float64   `json:"total"`
+	ItemsTotal     *float64   `json:"items_total"`  // sum of the line items read
+	NeedsReview    bool       `json:"needs_review"` // ItemsTotal does not match the printed amounts
+
+	ContentHash    string     `gorm:"index" json:"content_hash"` // SHA-256 of the stored pages
+	PerceptualHash string     `json:"perceptual_hash"`           // dHash of the first page, empty for a PDF page
+	DuplicateOfID  *uuid.UUID `gorm:"type:uuid" json:"duplicate_of_id,omitempty"`
+
+	User      *User          `gorm:"foreignKey:UserID"`
+	FoodItems []*FoodItem    `gorm:"foreignKey:ReceiptScanID"`
+	Pages     []*ReceiptPage `gorm:"foreignKey:ReceiptScanID" json:"pages,omitempty"`
 	Timestamp
 }
@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Session struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	RefreshTokenHash string     `json:"-"`
+	PreviousHash     string     `json:"-"` // the refresh token rotated away last, presenting it again is reuse
+	DeviceName       string     `json:"device_name"`
+	IPAddress        string     `json:"ip_address"`
+	UserAgent        string     `json:"user_agent"`
+	AMR              string     `json:"amr"` // comma separated authentication methods, e.g. "pwd,otp"
+	LastSeenAt       time.Time  `gorm:"type:timestamp" json:"last_seen_at"`
+	ExpiresAt        time.Time  `gorm:"type:timestamp" json:"expires_at"`
+	RevokedAt        *time.Time `gorm:"type:timestamp" json:"revoked_at,omitempty"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+	Timestamp
+}
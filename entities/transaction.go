@@ -1,13 +1,24 @@
 package entities
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type Transaction struct {
-	ID      uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID  uuid.UUID `json:"user_id"`
-	Status  string    `json:"status"`
-	Invoice string    `json:"invoice"`
-	OrderID string    `json:"order_id"`
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID     *uuid.UUID `gorm:"type:uuid" json:"user_id"` // nil once the owner deleted their account
+	Status     string     `json:"status"`
+	Invoice    string     `json:"invoice"`
+	OrderID    string     `json:"order_id"`
+	Amount     int64      `json:"amount"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	ReceiptURL string     `json:"receipt_url,omitempty"`
+
+	RefundedAmount int64 `gorm:"default:0" json:"refunded_amount"`
+
+	Gateway          string `gorm:"default:midtrans" json:"gateway"`
+	GatewayReference string `json:"gateway_reference"`
 
 	User *User `gorm:"foreignKey:UserID"`
 	Timestamp
@@ -2,6 +2,7 @@ package entities
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type User struct {
@@ -15,6 +16,19 @@ type User struct {
 	ProfilePicture string    `json:"profile_picture"`
 	Role           string    `json:"role"`
 	Verified       bool      `gorm:"default:false" json:"verified"`
+	MFAEnabled     bool      `gorm:"default:false" json:"mfa_enabled"`
+	MFASecret      string    `json:"-"` // AES encrypted TOTP secret
+	MFALastStep    int64     `json:"-"` // last accepted TOTP time step, blocks code replay
+
+	SuspendedAt     *time.Time `gorm:"type:timestamp" json:"suspended_at,omitempty"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+
+	// DeletionScheduledAt is set while a deletion request is in its cooling
+	// off period, the account is erased once it passes.
+	DeletionScheduledAt *time.Time `gorm:"type:timestamp;index" json:"deletion_scheduled_at,omitempty"`
+
+	// Roles are granted on top of Role, see domain.DefaultRoles.
+	Roles []Role `gorm:"many2many:user_roles" json:"roles,omitempty"`
 
 	Timestamp
 }
@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a User to an account at an external OpenID Connect
+// provider, identified by the provider's stable subject id.
+type UserIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_user_identity_user_provider" json:"user_id"`
+	Provider string    `gorm:"uniqueIndex:idx_user_identity_subject;uniqueIndex:idx_user_identity_user_provider" json:"provider"`
+	Subject  string    `gorm:"uniqueIndex:idx_user_identity_subject" json:"subject"`
+	Email    string    `json:"email"`
+
+	User *User `gorm:"foreignKey:UserID" json:"-"`
+	Timestamp
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/admin"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	AdminHandler interface {
+		GetUsers(c *fiber.Ctx) error
+		GetUser(c *fiber.Ctx) error
+		SuspendUser(c *fiber.Ctx) error
+		UnsuspendUser(c *fiber.Ctx) error
+		VerifyUser(c *fiber.Ctx) error
+		UpdateSubscription(c *fiber.Ctx) error
+		GetTransactions(c *fiber.Ctx) error
+		GetPaymentEvents(c *fiber.Ctx) error
+		GetWasteStatistics(c *fiber.Ctx) error
+	}
+
+	adminHandler struct {
+		adminService admin.AdminService
+		validator    *validator.Validate
+	}
+)
+
+func NewAdminHandler(adminService admin.AdminService, validator *validator.Validate) AdminHandler {
+	return &adminHandler{
+		adminService: adminService,
+		validator:    validator,
+	}
+}
+
+func (h *adminHandler) GetUsers(c *fiber.Ctx) error {
+	meta := pagination.New(c)
+	if c.Query("sort_by") == "" {
+		meta.SortBy = "created_at"
+		meta.Sort = "desc"
+	}
+
+	res, err := h.adminService.SearchUsers(c.Context(), meta)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetUsers, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetUsers)
+}
+
+func (h *adminHandler) GetUser(c *fiber.Ctx) error {
+	res, err := h.adminService.GetUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return presenters.ErrorResponse(c, userErrorStatus(err), domain.MessageFailedGetUser, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetUser)
+}
+
+func (h *adminHandler) SuspendUser(c *fiber.Ctx) error {
+	adminID := c.Locals("user_id").(string)
+
+	req := new(domain.SuspendUserRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.adminService.SuspendUser(c.Context(), adminID, c.Params("id"), *req)
+	if err != nil {
+		return presenters.ErrorResponse(c, userErrorStatus(err), domain.MessageFailedSuspendUser, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessSuspendUser)
+}
+
+func (h *adminHandler) UnsuspendUser(c *fiber.Ctx) error {
+	res, err := h.adminService.UnsuspendUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return presenters.ErrorResponse(c, userErrorStatus(err), domain.MessageFailedUnsuspendUser, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessUnsuspendUser)
+}
+
+func (h *adminHandler) VerifyUser(c *fiber.Ctx) error {
+	res, err := h.adminService.VerifyUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return presenters.ErrorResponse(c, userErrorStatus(err), domain.MessageFailedVerifyUser, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessVerifyUser)
+}
+
+func (h *adminHandler) UpdateSubscription(c *fiber.Ctx) error {
+	req := new(domain.UpdateSubscriptionRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.adminService.UpdateSubscription(c.Context(), c.Params("id"), *req)
+	if err != nil {
+		return presenters.ErrorResponse(c, userErrorStatus(err), domain.MessageFailedUpdateSubscription, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessUpdateSubscription)
+}
+
+func (h *adminHandler) GetTransactions(c *fiber.Ctx) error {
+	meta := pagination.New(c)
+	if c.Query("sort_by") == "" {
+		meta.SortBy = "created_at"
+		meta.Sort = "desc"
+	}
+
+	res, err := h.adminService.GetTransactions(c.Context(), meta)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetTransactions, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetTransactions)
+}
+
+func (h *adminHandler) GetPaymentEvents(c *fiber.Ctx) error {
+	res, err := h.adminService.GetPaymentEvents(c.Context(), c.Params("id"))
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetPaymentEvents, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetPaymentEvents)
+}
+
+func (h *adminHandler) GetWasteStatistics(c *fiber.Ctx) error {
+	res, err := h.adminService.GetWasteStatistics(c.Context())
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusInternalServerError, domain.MessageFailedGetWasteStatistics, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetWasteStatistics)
+}
+
+func userErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
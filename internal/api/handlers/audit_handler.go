@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/audit"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	AuditHandler interface {
+		GetAuditLogs(c *fiber.Ctx) error
+	}
+
+	auditHandler struct {
+		auditService audit.AuditService
+	}
+)
+
+func NewAuditHandler(auditService audit.AuditService) AuditHandler {
+	return &auditHandler{
+		auditService: auditService,
+	}
+}
+
+func (h *auditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	meta := pagination.New(c)
+	if c.Query("sort_by") == "" {
+		meta.Sort = "desc"
+	}
+
+	res, err := h.auditService.GetLogs(c.Context(), meta)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetAuditLogs, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetAuditLogs)
+}
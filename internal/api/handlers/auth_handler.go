@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/jwt"
+	"Go-Starter-Template/pkg/session"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	AuthHandler interface {
+		Refresh(c *fiber.Ctx) error
+		Logout(c *fiber.Ctx) error
+		GetSessions(c *fiber.Ctx) error
+		RevokeSession(c *fiber.Ctx) error
+		RevokeOtherSessions(c *fiber.Ctx) error
+		JWKS(c *fiber.Ctx) error
+	}
+
+	authHandler struct {
+		sessionService session.SessionService
+		jwtService     jwt.JWTService
+		validator      *validator.Validate
+	}
+)
+
+func NewAuthHandler(sessionService session.SessionService, jwtService jwt.JWTService, validator *validator.Validate) AuthHandler {
+	return &authHandler{
+		sessionService: sessionService,
+		jwtService:     jwtService,
+		validator:      validator,
+	}
+}
+
+func (h *authHandler) Refresh(c *fiber.Ctx) error {
+	req := new(domain.RefreshTokenRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRefreshToken, err)
+	}
+
+	res, err := h.sessionService.Refresh(c.Context(), req.RefreshToken, sessionClient(c))
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedRefreshToken, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessRefreshToken)
+}
+
+func (h *authHandler) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	sessionID := c.Locals("session_id").(string)
+
+	if err := h.sessionService.RevokeSession(c.Context(), sessionID, userID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedLogout, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessLogout)
+}
+
+func (h *authHandler) GetSessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	sessionID := c.Locals("session_id").(string)
+
+	res, err := h.sessionService.GetSessions(c.Context(), userID, sessionID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetSessions, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetSessions)
+}
+
+func (h *authHandler) RevokeSession(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.sessionService.RevokeSession(c.Context(), c.Params("id"), userID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusNotFound, domain.MessageFailedRevokeSession, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessRevokeSession)
+}
+
+// RevokeOtherSessions signs out every device except the one making the request.
+func (h *authHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	sessionID := c.Locals("session_id").(string)
+
+	if err := h.sessionService.RevokeAllSessions(c.Context(), userID, sessionID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRevokeSession, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessRevokeSession)
+}
+
+// JWKS serves the raw key set, verifiers expect the RFC 7517 document rather
+// than the usual response envelope.
+func (h *authHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(h.jwtService.JWKS())
+}
+
+func sessionClient(c *fiber.Ctx) domain.SessionClient {
+	return domain.SessionClient{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
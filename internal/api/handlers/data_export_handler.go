@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/dataexport"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	DataExportHandler interface {
+		RequestExport(c *fiber.Ctx) error
+		GetExports(c *fiber.Ctx) error
+		DownloadExport(c *fiber.Ctx) error
+	}
+
+	dataExportHandler struct {
+		dataExportService dataexport.DataExportService
+	}
+)
+
+func NewDataExportHandler(dataExportService dataexport.DataExportService) DataExportHandler {
+	return &dataExportHandler{
+		dataExportService: dataExportService,
+	}
+}
+
+func (h *dataExportHandler) RequestExport(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.dataExportService.RequestExport(c.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataExportInProgress) {
+			return presenters.ErrorResponse(c, fiber.StatusConflict, domain.MessageFailedRequestDataExport, err)
+		}
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRequestDataExport, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusAccepted, domain.MessageSuccessRequestDataExport)
+}
+
+func (h *dataExportHandler) GetExports(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.dataExportService.GetExports(c.Context(), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetDataExports, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetDataExports)
+}
+
+func (h *dataExportHandler) DownloadExport(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.dataExportService.GetDownloadLink(c.Context(), userID, c.Params("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDataExportNotFound):
+			return presenters.ErrorResponse(c, fiber.StatusNotFound, domain.MessageFailedDownloadExport, err)
+		case errors.Is(err, domain.ErrDataExportNotReady):
+			return presenters.ErrorResponse(c, fiber.StatusConflict, domain.MessageFailedDownloadExport, err)
+		}
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedDownloadExport, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessDownloadExport)
+}
@@ -15,6 +15,7 @@ type (
 		AddFoodItem(c *fiber.Ctx) error
 		UpdateFoodItem(c *fiber.Ctx) error
 		DeleteFoodItem(c *fiber.Ctx) error
+		RemoveFoodItem(c *fiber.Ctx) error
 		GetFoodItems(c *fiber.Ctx) error
 		GetFoodItemDetails(c *fiber.Ctx) error
 		UploadFoodImage(c *fiber.Ctx) error
@@ -24,6 +25,9 @@ type (
 		MarkAsDamaged(c *fiber.Ctx) error
 		GetDashboardStats(c *fiber.Ctx) error
 		DetectFoodAge(c *fiber.Ctx) error
+		GetTrash(c *fiber.Ctx) error
+		RestoreFoodItem(c *fiber.Ctx) error
+		PurgeFoodItem(c *fiber.Ctx) error
 	}
 
 	foodHandler struct {
@@ -90,6 +94,63 @@ func (h *foodHandler) DeleteFoodItem(c *fiber.Ctx) error {
 	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessDeleteFoodItem)
 }
 
+func (h *foodHandler) RemoveFoodItem(c *fiber.Ctx) error {
+	if err := h.foodService.RemoveFoodItem(c.Context(), c.Params("id")); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedDeleteFoodItem, err)
+	}
+
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessDeleteFoodItem)
+}
+
+func (h *foodHandler) GetTrash(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+
+	items, count, err := h.foodService.GetTrash(c.Context(), userID, page, limit)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetTrash, err)
+	}
+
+	return presenters.SuccessResponse(c, fiber.Map{
+		"items": items,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total":       count,
+			"total_pages": (count + int64(limit) - 1) / int64(limit),
+		},
+	}, fiber.StatusOK, domain.MessageSuccessGetTrash)
+}
+
+func (h *foodHandler) RestoreFoodItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.foodService.RestoreFoodItem(c.Context(), c.Params("id"), userID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRestoreFoodItem, err)
+	}
+
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessRestoreFoodItem)
+}
+
+func (h *foodHandler) PurgeFoodItem(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.foodService.PurgeFoodItem(c.Context(), c.Params("id"), userID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedPurgeFoodItem, err)
+	}
+
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessPurgeFoodItem)
+}
+
 func (h *foodHandler) GetFoodItems(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 	status := c.Query("status", "all")
@@ -141,12 +202,7 @@ func (h *foodHandler) UploadFoodImage(c *fiber.Ctx) error {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
 	}
 
-	file, err := c.FormFile("image")
-	if err != nil {
-		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
-	}
-
-	req.Image = file
+	req.Image, _ = c.FormFile("image")
 
 	if err := h.validator.Struct(req); err != nil {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
@@ -166,12 +222,14 @@ func (h *foodHandler) UploadReceipt(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 	req := new(domain.UploadReceiptRequest)
 
-	file, err := c.FormFile("receipt_image")
-	if err != nil {
+	if err := c.BodyParser(req); err != nil {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
 	}
 
-	req.ReceiptImage = file
+	req.ReceiptImage, _ = c.FormFile("receipt_image")
+	if form, err := c.MultipartForm(); err == nil {
+		req.ReceiptImages = form.File["receipt_images"]
+	}
 
 	if err := h.validator.Struct(req); err != nil {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUploadReceipt, err)
@@ -179,6 +237,9 @@ func (h *foodHandler) UploadReceipt(c *fiber.Ctx) error {
 
 	res, err := h.foodService.UploadReceipt(c.Context(), *req, userID)
 	if err != nil {
+		if errors.Is(err, domain.ErrDuplicateReceipt) {
+			return presenters.ErrorResponseWithData(c, fiber.StatusConflict, domain.MessageFailedUploadReceipt, err, res)
+		}
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUploadReceipt, err)
 	}
 
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/mfa"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	MFAHandler interface {
+		Enroll(c *fiber.Ctx) error
+		Enable(c *fiber.Ctx) error
+		Disable(c *fiber.Ctx) error
+		RegenerateRecoveryCodes(c *fiber.Ctx) error
+		Verify(c *fiber.Ctx) error
+		GetPolicies(c *fiber.Ctx) error
+		SetPolicy(c *fiber.Ctx) error
+	}
+
+	mfaHandler struct {
+		mfaService mfa.MFAService
+		validator  *validator.Validate
+	}
+)
+
+func NewMFAHandler(mfaService mfa.MFAService, validator *validator.Validate) MFAHandler {
+	return &mfaHandler{
+		mfaService: mfaService,
+		validator:  validator,
+	}
+}
+
+func (h *mfaHandler) Enroll(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.mfaService.Enroll(c.Context(), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedMFAEnroll, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessMFAEnroll)
+}
+
+func (h *mfaHandler) Enable(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	req, err := h.parseCode(c)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.mfaService.Enable(c.Context(), userID, req.Code)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedMFAEnable, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessMFAEnable)
+}
+
+func (h *mfaHandler) Disable(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	req, err := h.parseCode(c)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	if err := h.mfaService.Disable(c.Context(), userID, req.Code); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedMFADisable, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessMFADisable)
+}
+
+func (h *mfaHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	req, err := h.parseCode(c)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.mfaService.RegenerateRecoveryCodes(c.Context(), userID, req.Code)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedMFARecoveryCodes, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessMFARecoveryCodes)
+}
+
+func (h *mfaHandler) Verify(c *fiber.Ctx) error {
+	req := new(domain.MFAVerifyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedMFAVerify, err)
+	}
+
+	res, err := h.mfaService.Verify(c.Context(), *req, sessionClient(c))
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedMFAVerify, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessMFAVerify)
+}
+
+func (h *mfaHandler) GetPolicies(c *fiber.Ctx) error {
+	res, err := h.mfaService.GetPolicies(c.Context())
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetMFAPolicies, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetMFAPolicies)
+}
+
+func (h *mfaHandler) SetPolicy(c *fiber.Ctx) error {
+	req := new(domain.MFAPolicyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.mfaService.SetPolicy(c.Context(), c.Params("role"), *req)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUpdateMFAPolicy, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessUpdateMFAPolicy)
+}
+
+func (h *mfaHandler) parseCode(c *fiber.Ctx) (*domain.MFACodeRequest, error) {
+	req := new(domain.MFACodeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return nil, err
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/oauth"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	OAuthHandler interface {
+		Login(c *fiber.Ctx) error
+		Callback(c *fiber.Ctx) error
+		GetIdentities(c *fiber.Ctx) error
+		LinkIdentity(c *fiber.Ctx) error
+		UnlinkIdentity(c *fiber.Ctx) error
+	}
+
+	oauthHandler struct {
+		oauthService oauth.OAuthService
+		validator    *validator.Validate
+	}
+)
+
+func NewOAuthHandler(oauthService oauth.OAuthService, validator *validator.Validate) OAuthHandler {
+	return &oauthHandler{
+		oauthService: oauthService,
+		validator:    validator,
+	}
+}
+
+func (h *oauthHandler) Login(c *fiber.Ctx) error {
+	res, err := h.oauthService.StartLogin(c.Context(), c.Params("provider"))
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedOAuthLogin, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessOAuthLogin)
+}
+
+func (h *oauthHandler) Callback(c *fiber.Ctx) error {
+	req := new(domain.OAuthCallbackRequest)
+	if err := c.QueryParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedOAuthCallback, err)
+	}
+
+	res, err := h.oauthService.Callback(c.Context(), c.Params("provider"), *req, sessionClient(c))
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedOAuthCallback, err)
+	}
+	if res.Identity != nil {
+		return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessLinkIdentity)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessOAuthCallback)
+}
+
+func (h *oauthHandler) GetIdentities(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.oauthService.GetIdentities(c.Context(), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetIdentities, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetIdentities)
+}
+
+// LinkIdentity starts the same authorization flow as Login, the callback
+// attaches the provider account to the signed in user instead of signing in.
+func (h *oauthHandler) LinkIdentity(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.oauthService.StartLink(c.Context(), c.Params("provider"), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedLinkIdentity, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessOAuthLogin)
+}
+
+func (h *oauthHandler) UnlinkIdentity(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.oauthService.Unlink(c.Context(), c.Params("provider"), userID); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUnlinkIdentity, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessUnlinkIdentity)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/payment"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+)
+
+type (
+	PaymentHandler interface {
+		CreateTransaction(c *fiber.Ctx) error
+		WebhookHandler(c *fiber.Ctx) error
+		RefundTransaction(c *fiber.Ctx) error
+		CancelTransaction(c *fiber.Ctx) error
+	}
+	paymentHandler struct {
+		paymentService payment.PaymentService
+		Validator      *validator.Validate
+	}
+)
+
+func NewPaymentHandler(paymentService payment.PaymentService, validator *validator.Validate) PaymentHandler {
+	return &paymentHandler{
+		paymentService: paymentService,
+		Validator:      validator,
+	}
+}
+
+func (h *paymentHandler) CreateTransaction(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	var req domain.PaymentRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedCreateTransaction, err)
+	}
+	res, err := h.paymentService.CreateTransaction(c.Context(), req, userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedCreateTransaction, err)
+	}
+
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessCreateTransaction)
+}
+
+func (h *paymentHandler) WebhookHandler(c *fiber.Ctx) error {
+	res, err := h.paymentService.HandleWebhook(c.Context(), c.Params("gateway"), http.Header(c.GetReqHeaders()), c.Body())
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessWebhook)
+}
+
+func (h *paymentHandler) RefundTransaction(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(string)
+	req := new(domain.RefundTransactionRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRefundTransaction, err)
+	}
+
+	res, err := h.paymentService.RefundTransaction(c.Context(), c.Params("id"), *req, actorID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRefundTransaction, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessRefundTransaction)
+}
+
+func (h *paymentHandler) CancelTransaction(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.paymentService.CancelTransaction(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedCancelTransaction, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessCancelTransaction)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/rbac"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	RBACHandler interface {
+		GetRoles(c *fiber.Ctx) error
+		GetPermissions(c *fiber.Ctx) error
+		UpdateRolePermissions(c *fiber.Ctx) error
+		GetUserRoles(c *fiber.Ctx) error
+		AssignRole(c *fiber.Ctx) error
+		RemoveRole(c *fiber.Ctx) error
+	}
+
+	rbacHandler struct {
+		rbacService rbac.RBACService
+		validator   *validator.Validate
+	}
+)
+
+func NewRBACHandler(rbacService rbac.RBACService, validator *validator.Validate) RBACHandler {
+	return &rbacHandler{
+		rbacService: rbacService,
+		validator:   validator,
+	}
+}
+
+func (h *rbacHandler) GetRoles(c *fiber.Ctx) error {
+	res, err := h.rbacService.GetRoles(c.Context())
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetRoles, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetRoles)
+}
+
+func (h *rbacHandler) GetPermissions(c *fiber.Ctx) error {
+	res, err := h.rbacService.GetPermissions(c.Context())
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetPermissions, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetPermissions)
+}
+
+func (h *rbacHandler) UpdateRolePermissions(c *fiber.Ctx) error {
+	req := new(domain.UpdateRolePermissionsRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.rbacService.UpdateRolePermissions(c.Context(), c.Params("role"), *req)
+	if err != nil {
+		return presenters.ErrorResponse(c, rbacErrorStatus(err), domain.MessageFailedUpdateRole, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessUpdateRole)
+}
+
+func (h *rbacHandler) GetUserRoles(c *fiber.Ctx) error {
+	res, err := h.rbacService.GetUserRoles(c.Context(), c.Params("id"))
+	if err != nil {
+		return presenters.ErrorResponse(c, rbacErrorStatus(err), domain.MessageFailedGetUserRoles, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetUserRoles)
+}
+
+func (h *rbacHandler) AssignRole(c *fiber.Ctx) error {
+	res, err := h.rbacService.AssignRole(c.Context(), c.Params("id"), c.Params("role"))
+	if err != nil {
+		return presenters.ErrorResponse(c, rbacErrorStatus(err), domain.MessageFailedAssignRole, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessAssignRole)
+}
+
+func (h *rbacHandler) RemoveRole(c *fiber.Ctx) error {
+	res, err := h.rbacService.RemoveRole(c.Context(), c.Params("id"), c.Params("role"))
+	if err != nil {
+		return presenters.ErrorResponse(c, rbacErrorStatus(err), domain.MessageFailedRemoveRole, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessRemoveRole)
+}
+
+func rbacErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrRoleNotFound) {
+		return fiber.StatusNotFound
+	}
+	return fiber.StatusBadRequest
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/transaction"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	TransactionHandler interface {
+		GetTransactions(c *fiber.Ctx) error
+		GetTransactionDetail(c *fiber.Ctx) error
+		DownloadInvoice(c *fiber.Ctx) error
+		DownloadReceipt(c *fiber.Ctx) error
+	}
+
+	transactionHandler struct {
+		transactionService transaction.TransactionService
+	}
+)
+
+func NewTransactionHandler(transactionService transaction.TransactionService) TransactionHandler {
+	return &transactionHandler{
+		transactionService: transactionService,
+	}
+}
+
+func (h *transactionHandler) GetTransactions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	meta := pagination.New(c)
+	if c.Query("sort_by") == "" {
+		meta.SortBy = "created_at"
+		meta.Sort = "desc"
+	}
+
+	res, err := h.transactionService.GetTransactions(c.Context(), userID, meta)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetTransactions, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetTransactions)
+}
+
+func (h *transactionHandler) GetTransactionDetail(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	res, err := h.transactionService.GetTransactionDetail(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusNotFound, domain.MessageFailedGetTransactionDetail, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessGetTransactionDetail)
+}
+
+func (h *transactionHandler) DownloadInvoice(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	doc, err := h.transactionService.GetInvoice(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGenerateInvoice, err)
+	}
+	return sendPDF(c, doc)
+}
+
+func (h *transactionHandler) DownloadReceipt(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	doc, err := h.transactionService.GetReceipt(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGenerateReceipt, err)
+	}
+	return sendPDF(c, doc)
+}
+
+func sendPDF(c *fiber.Ctx, doc domain.TransactionDocument) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", doc.Filename))
+	return c.Status(fiber.StatusOK).Send(doc.Content)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"Go-Starter-Template/pkg/upload"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	UploadHandler interface {
+		CreateUpload(c *fiber.Ctx) error
+	}
+
+	uploadHandler struct {
+		uploadService upload.UploadService
+		validator     *validator.Validate
+	}
+)
+
+func NewUploadHandler(uploadService upload.UploadService, validator *validator.Validate) UploadHandler {
+	return &uploadHandler{
+		uploadService: uploadService,
+		validator:     validator,
+	}
+}
+
+func (h *uploadHandler) CreateUpload(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	req := new(domain.CreateUploadRequest)
+
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.uploadService.CreateUpload(c.Context(), userID, *req)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedCreateUpload, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusCreated, domain.MessageSuccessCreateUpload)
+}
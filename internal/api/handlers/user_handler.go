@@ -3,7 +3,6 @@ package handlers
 import (
 	"Go-Starter-Template/domain"
 	"Go-Starter-Template/internal/api/presenters"
-	"Go-Starter-Template/pkg/jwt"
 	"Go-Starter-Template/pkg/user"
 	"errors"
 	"github.com/go-playground/validator/v10"
@@ -20,19 +19,22 @@ type (
 		UpdateUser(c *fiber.Ctx) error
 		ForgotPassword(c *fiber.Ctx) error
 		ResetPassword(c *fiber.Ctx) error
+		ChangePassword(c *fiber.Ctx) error
+		UnlockAccount(c *fiber.Ctx) error
+		ConfirmEmailChange(c *fiber.Ctx) error
+		DeleteAccount(c *fiber.Ctx) error
+		CancelDeletion(c *fiber.Ctx) error
 	}
 	userHandler struct {
 		UserService user.UserService
 		Validator   *validator.Validate
-		JWTService  jwt.JWTService
 	}
 )
 
-func NewUserHandler(userService user.UserService, validator *validator.Validate, jwtService jwt.JWTService) UserHandler {
+func NewUserHandler(userService user.UserService, validator *validator.Validate) UserHandler {
 	return &userHandler{
 		UserService: userService,
 		Validator:   validator,
-		JWTService:  jwtService,
 	}
 }
 
@@ -62,9 +64,15 @@ func (h *userHandler) Login(c *fiber.Ctx) error {
 	if err := h.Validator.Struct(req); err != nil {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRegister, err)
 	}
-	res, err := h.UserService.Login(c.Context(), *req)
+	res, err := h.UserService.Login(c.Context(), *req, sessionClient(c))
 	if err != nil {
-		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedRegister, err)
+		if errors.Is(err, domain.ErrAccountLocked) || errors.Is(err, domain.ErrTooManyLoginAttempts) {
+			return presenters.ErrorResponse(c, fiber.StatusTooManyRequests, domain.MessageFailedLogin, err)
+		}
+		if errors.Is(err, domain.ErrAccountSuspended) {
+			return presenters.ErrorResponse(c, fiber.StatusForbidden, domain.MessageFailedLogin, err)
+		}
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedLogin, err)
 	}
 	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessLogin)
 }
@@ -160,11 +168,6 @@ func (h *userHandler) ResetPassword(c *fiber.Ctx) error {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetToken, errors.New("token required"))
 	}
 
-	claims, err := h.JWTService.ValidateTokenForgetPassword(token)
-	if err != nil {
-		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedTokenInvalid, err)
-	}
-
 	req := new(domain.ResetPasswordRequest)
 	if err := c.BodyParser(req); err != nil {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
@@ -174,14 +177,90 @@ func (h *userHandler) ResetPassword(c *fiber.Ctx) error {
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
 	}
 
-	email, ok := claims["email"].(string)
-	if !ok {
-		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedTokenInvalid, err)
+	if err := h.UserService.ResetPassword(c.Context(), token, req.Password); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUpdatePassword, err)
+	}
+
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessUpdatePassword)
+}
+
+func (h *userHandler) ChangePassword(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	sessionID := c.Locals("session_id").(string)
+
+	req := new(domain.ChangePasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
 	}
 
-	if err := h.UserService.ResetPassword(c.Context(), email, req.Password); err != nil {
+	if err := h.UserService.ChangePassword(c.Context(), userID, sessionID, *req); err != nil {
+		if errors.Is(err, domain.ErrCurrentPasswordInvalid) {
+			return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedUpdatePassword, err)
+		}
 		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUpdatePassword, err)
 	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessChangePassword)
+}
 
-	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessUpdatePassword)
+func (h *userHandler) UnlockAccount(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetToken, domain.ErrTokenInvalid)
+	}
+
+	if err := h.UserService.UnlockAccount(c.Context(), token); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedUnlockAccount, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessUnlockAccount)
+}
+
+func (h *userHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedGetToken, domain.ErrTokenInvalid)
+	}
+
+	res, err := h.UserService.ConfirmEmailChange(c.Context(), token)
+	if err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedConfirmEmail, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusOK, domain.MessageSuccessConfirmEmailChange)
+}
+
+func (h *userHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	sessionID := c.Locals("session_id").(string)
+
+	req := new(domain.DeleteAccountRequest)
+	if err := c.BodyParser(req); err != nil {
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedBodyRequest, err)
+	}
+
+	res, err := h.UserService.RequestDeletion(c.Context(), userID, sessionID, *req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrCurrentPasswordInvalid):
+			return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedDeleteAccount, err)
+		case errors.Is(err, domain.ErrDeletionScheduled):
+			return presenters.ErrorResponse(c, fiber.StatusConflict, domain.MessageFailedDeleteAccount, err)
+		}
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedDeleteAccount, err)
+	}
+	return presenters.SuccessResponse(c, res, fiber.StatusAccepted, domain.MessageSuccessRequestDeletion)
+}
+
+func (h *userHandler) CancelDeletion(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.UserService.CancelDeletion(c.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrDeletionNotScheduled) {
+			return presenters.ErrorResponse(c, fiber.StatusConflict, domain.MessageFailedDeleteAccount, err)
+		}
+		return presenters.ErrorResponse(c, fiber.StatusBadRequest, domain.MessageFailedDeleteAccount, err)
+	}
+	return presenters.SuccessResponse(c, nil, fiber.StatusOK, domain.MessageSuccessCancelDeletion)
 }
@@ -28,3 +28,16 @@ func ErrorResponse(c *fiber.Ctx, statusCode int, message string, err error) erro
 
 	return c.Status(statusCode).JSON(resp)
 }
+
+// ErrorResponseWithData is ErrorResponse for failures the client can act
+// on, data tells it how.
+func ErrorResponseWithData(c *fiber.Ctx, statusCode int, message string, err error, data interface{}) error {
+	resp := Response{
+		Success: false,
+		Message: message,
+		Error:   err.Error(),
+		Data:    data,
+	}
+
+	return c.Status(statusCode).JSON(resp)
+}
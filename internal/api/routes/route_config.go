@@ -1,25 +1,40 @@
 package routes
 
 import (
+	"Go-Starter-Template/domain"
 	"Go-Starter-Template/internal/api/handlers"
 	"Go-Starter-Template/internal/middleware"
 	"Go-Starter-Template/pkg/jwt"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
 type Config struct {
-	App             *fiber.App
-	UserHandler     handlers.UserHandler
-	FoodHandler     handlers.FoodHandler
-	MidtransHandler handlers.MidtransHandler
-	Middleware      middleware.Middleware
-	JWTService      jwt.JWTService
+	App                *fiber.App
+	UserHandler        handlers.UserHandler
+	FoodHandler        handlers.FoodHandler
+	PaymentHandler     handlers.PaymentHandler
+	TransactionHandler handlers.TransactionHandler
+	AuthHandler        handlers.AuthHandler
+	OAuthHandler       handlers.OAuthHandler
+	MFAHandler         handlers.MFAHandler
+	AdminHandler       handlers.AdminHandler
+	RBACHandler        handlers.RBACHandler
+	AuditHandler       handlers.AuditHandler
+	DataExportHandler  handlers.DataExportHandler
+	UploadHandler      handlers.UploadHandler
+	Middleware         middleware.Middleware
+	JWTService         jwt.JWTService
 }
 
 func (c *Config) Setup() {
 	c.App.Use(c.Middleware.CORSMiddleware())
+	c.App.Use(c.Middleware.RequestContext())
 	c.User()
+	c.Auth()
 	c.FoodItems()
+	c.Uploads()
+	c.Admin()
 	c.GuestRoute()
 	c.AuthRoute()
 }
@@ -29,14 +44,81 @@ func (c *Config) User() {
 	// user routes
 	{
 		user.Post("/register", c.UserHandler.Register)
-		user.Post("/login", c.UserHandler.Login)
-		user.Post("/send_verify", c.UserHandler.SendVerificationEmail)
+		user.Post("/login", c.Middleware.RateLimit("login", 10, time.Minute), c.UserHandler.Login)
+		user.Post("/send_verify", c.Middleware.RateLimit("send_verify", 3, 15*time.Minute), c.UserHandler.SendVerificationEmail)
 		user.Get("/verify", c.UserHandler.VerifyEmail)
+		user.Get("/unlock", c.UserHandler.UnlockAccount)
+		user.Get("/email/confirm", c.UserHandler.ConfirmEmailChange)
 		user.Get("/me", c.Middleware.AuthMiddleware(c.JWTService), c.UserHandler.Me)
 		user.Patch("/update", c.Middleware.AuthMiddleware(c.JWTService), c.UserHandler.UpdateUser)
-		user.Post("/forget", c.UserHandler.ForgotPassword)
+		user.Post("/forget", c.Middleware.RateLimit("forget", 3, 15*time.Minute), c.UserHandler.ForgotPassword)
 		user.Post("/reset", c.UserHandler.ResetPassword)
-		user.Post("/subscribe", c.Middleware.AuthMiddleware(c.JWTService), c.MidtransHandler.CreateTransaction)
+		user.Patch("/password", c.Middleware.AuthMiddleware(c.JWTService), c.Middleware.RateLimit("change_password", 5, 15*time.Minute), c.UserHandler.ChangePassword)
+		user.Post("/delete", c.Middleware.AuthMiddleware(c.JWTService), c.Middleware.RateLimit("delete_account", 5, 15*time.Minute), c.UserHandler.DeleteAccount)
+		user.Post("/delete/cancel", c.Middleware.AuthMiddleware(c.JWTService), c.UserHandler.CancelDeletion)
+		user.Post("/exports", c.Middleware.AuthMiddleware(c.JWTService), c.Middleware.RateLimit("data_export", 3, 24*time.Hour), c.DataExportHandler.RequestExport)
+		user.Get("/exports", c.Middleware.AuthMiddleware(c.JWTService), c.DataExportHandler.GetExports)
+		user.Get("/exports/:id/download", c.Middleware.AuthMiddleware(c.JWTService), c.DataExportHandler.DownloadExport)
+		user.Post("/subscribe", c.Middleware.AuthMiddleware(c.JWTService), c.PaymentHandler.CreateTransaction)
+		user.Get("/transactions", c.Middleware.AuthMiddleware(c.JWTService), c.TransactionHandler.GetTransactions)
+		user.Get("/transactions/:id", c.Middleware.AuthMiddleware(c.JWTService), c.TransactionHandler.GetTransactionDetail)
+		user.Get("/transactions/:id/invoice", c.Middleware.AuthMiddleware(c.JWTService), c.TransactionHandler.DownloadInvoice)
+		user.Get("/transactions/:id/receipt", c.Middleware.AuthMiddleware(c.JWTService), c.TransactionHandler.DownloadReceipt)
+		user.Post("/transactions/:id/cancel", c.Middleware.AuthMiddleware(c.JWTService), c.PaymentHandler.CancelTransaction)
+		user.Get("/identities", c.Middleware.AuthMiddleware(c.JWTService), c.OAuthHandler.GetIdentities)
+		user.Post("/identities/:provider", c.Middleware.AuthMiddleware(c.JWTService), c.OAuthHandler.LinkIdentity)
+		user.Delete("/identities/:provider", c.Middleware.AuthMiddleware(c.JWTService), c.OAuthHandler.UnlinkIdentity)
+		user.Post("/mfa/enroll", c.Middleware.AuthMiddleware(c.JWTService), c.MFAHandler.Enroll)
+		user.Post("/mfa/enable", c.Middleware.AuthMiddleware(c.JWTService), c.MFAHandler.Enable)
+		user.Post("/mfa/disable", c.Middleware.AuthMiddleware(c.JWTService), c.MFAHandler.Disable)
+		user.Post("/mfa/recovery-codes", c.Middleware.AuthMiddleware(c.JWTService), c.MFAHandler.RegenerateRecoveryCodes)
+	}
+}
+
+func (c *Config) Auth() {
+	auth := c.App.Group("/api/v1/auth")
+	{
+		auth.Post("/refresh", c.AuthHandler.Refresh)
+		auth.Post("/logout", c.Middleware.AuthMiddleware(c.JWTService), c.AuthHandler.Logout)
+		auth.Get("/sessions", c.Middleware.AuthMiddleware(c.JWTService), c.AuthHandler.GetSessions)
+		auth.Delete("/sessions", c.Middleware.AuthMiddleware(c.JWTService), c.AuthHandler.RevokeOtherSessions)
+		auth.Delete("/sessions/:id", c.Middleware.AuthMiddleware(c.JWTService), c.AuthHandler.RevokeSession)
+		auth.Get("/oauth/:provider/login", c.OAuthHandler.Login)
+		auth.Get("/oauth/:provider/callback", c.OAuthHandler.Callback)
+		auth.Post("/mfa/verify", c.Middleware.RateLimit("mfa_verify", 10, time.Minute), c.MFAHandler.Verify)
+	}
+}
+
+func (c *Config) Admin() {
+	admin := c.App.Group("/api/v1/admin", c.Middleware.AuthMiddleware(c.JWTService), c.Middleware.RequireMFA())
+	{
+		admin.Get("/users", c.Middleware.RequirePermission(domain.PermUserRead), c.AdminHandler.GetUsers)
+		admin.Get("/users/:id", c.Middleware.RequirePermission(domain.PermUserRead), c.AdminHandler.GetUser)
+		admin.Post("/users/:id/suspend", c.Middleware.RequirePermission(domain.PermUserSuspend), c.AdminHandler.SuspendUser)
+		admin.Post("/users/:id/unsuspend", c.Middleware.RequirePermission(domain.PermUserSuspend), c.AdminHandler.UnsuspendUser)
+		admin.Post("/users/:id/verify", c.Middleware.RequirePermission(domain.PermUserVerify), c.AdminHandler.VerifyUser)
+		admin.Put("/users/:id/subscription", c.Middleware.RequirePermission(domain.PermSubscriptionEdit), c.AdminHandler.UpdateSubscription)
+		admin.Get("/users/:id/roles", c.Middleware.RequirePermission(domain.PermUserRead), c.RBACHandler.GetUserRoles)
+		admin.Put("/users/:id/roles/:role", c.Middleware.RequirePermission(domain.PermRBACManage), c.RBACHandler.AssignRole)
+		admin.Delete("/users/:id/roles/:role", c.Middleware.RequirePermission(domain.PermRBACManage), c.RBACHandler.RemoveRole)
+		admin.Get("/roles", c.Middleware.RequirePermission(domain.PermRBACManage), c.RBACHandler.GetRoles)
+		admin.Put("/roles/:role/permissions", c.Middleware.RequirePermission(domain.PermRBACManage), c.RBACHandler.UpdateRolePermissions)
+		admin.Get("/permissions", c.Middleware.RequirePermission(domain.PermRBACManage), c.RBACHandler.GetPermissions)
+		admin.Get("/transactions", c.Middleware.RequirePermission(domain.PermTransactionRead), c.AdminHandler.GetTransactions)
+		admin.Get("/transactions/:id/events", c.Middleware.RequirePermission(domain.PermTransactionRead), c.AdminHandler.GetPaymentEvents)
+		admin.Post("/transactions/:id/refund", c.Middleware.RequirePermission(domain.PermTransactionRefund), c.PaymentHandler.RefundTransaction)
+		admin.Delete("/food-items/:id", c.Middleware.RequirePermission(domain.PermFoodDeleteAny), c.FoodHandler.RemoveFoodItem)
+		admin.Get("/statistics/waste", c.Middleware.RequirePermission(domain.PermStatisticsRead), c.AdminHandler.GetWasteStatistics)
+		admin.Get("/audit-logs", c.Middleware.RequirePermission(domain.PermAuditRead), c.AuditHandler.GetAuditLogs)
+		admin.Get("/mfa-policies", c.Middleware.RequirePermission(domain.PermMFAPolicyEdit), c.MFAHandler.GetPolicies)
+		admin.Put("/mfa-policies/:role", c.Middleware.RequirePermission(domain.PermMFAPolicyEdit), c.MFAHandler.SetPolicy)
+	}
+}
+
+func (c *Config) Uploads() {
+	uploads := c.App.Group("/api/v1/uploads", c.Middleware.AuthMiddleware(c.JWTService))
+	{
+		uploads.Post("/", c.Middleware.RateLimit("create_upload", 30, time.Minute), c.UploadHandler.CreateUpload)
 	}
 }
 
@@ -44,7 +126,8 @@ func (c *Config) GuestRoute() {
 	c.App.Get("/api/ping", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"message": "pong, its works. test"})
 	})
-	c.App.Post("/webhook/midtrans", c.MidtransHandler.MidtransWebhookHandler)
+	c.App.Post("/webhook/:gateway", c.PaymentHandler.WebhookHandler)
+	c.App.Get("/.well-known/jwks.json", c.AuthHandler.JWKS)
 }
 
 func (c *Config) AuthRoute() {
@@ -64,20 +147,25 @@ func (c *Config) AuthRoute() {
 
 func (c *Config) FoodItems() {
 	foodItems := c.App.Group("/api/v1/food-items", c.Middleware.AuthMiddleware(c.JWTService))
-	foodItems.Get("/dashboard", c.FoodHandler.GetDashboardStats)
+	foodItems.Get("/dashboard", c.Middleware.RequirePermission(domain.PermFoodRead), c.FoodHandler.GetDashboardStats)
+
+	// Trash, registered before "/:id" so "trash" is not taken for an id
+	foodItems.Get("/trash", c.Middleware.RequirePermission(domain.PermFoodRead), c.FoodHandler.GetTrash)
+	foodItems.Post("/trash/:id/restore", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.RestoreFoodItem)
+	foodItems.Delete("/trash/:id", c.Middleware.RequirePermission(domain.PermFoodDelete), c.FoodHandler.PurgeFoodItem)
 
 	// Basic CRUD operations
-	foodItems.Post("", c.FoodHandler.AddFoodItem)
-	foodItems.Get("", c.FoodHandler.GetFoodItems)
-	foodItems.Get("/:id", c.FoodHandler.GetFoodItemDetails)
-	foodItems.Put("/:id", c.FoodHandler.UpdateFoodItem)
-	foodItems.Delete("/:id", c.FoodHandler.DeleteFoodItem)
+	foodItems.Post("", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.AddFoodItem)
+	foodItems.Get("", c.Middleware.RequirePermission(domain.PermFoodRead), c.FoodHandler.GetFoodItems)
+	foodItems.Get("/:id", c.Middleware.RequirePermission(domain.PermFoodRead), c.FoodHandler.GetFoodItemDetails)
+	foodItems.Put("/:id", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.UpdateFoodItem)
+	foodItems.Delete("/:id", c.Middleware.RequirePermission(domain.PermFoodDelete), c.FoodHandler.DeleteFoodItem)
 
 	// Special operations
-	foodItems.Post("/image", c.FoodHandler.UploadFoodImage)
-	foodItems.Post("/receipt-scan", c.FoodHandler.UploadReceipt)
-	foodItems.Get("/receipt-scan/:id", c.FoodHandler.GetReceiptScanResult)
-	foodItems.Post("/save-scanned", c.FoodHandler.SaveScannedItems)
-	foodItems.Post("/damaged", c.FoodHandler.MarkAsDamaged)
-	foodItems.Post("/detect-age", c.FoodHandler.DetectFoodAge)
+	foodItems.Post("/image", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.UploadFoodImage)
+	foodItems.Post("/receipt-scan", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.UploadReceipt)
+	foodItems.Get("/receipt-scan/:id", c.Middleware.RequirePermission(domain.PermFoodRead), c.FoodHandler.GetReceiptScanResult)
+	foodItems.Post("/save-scanned", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.SaveScannedItems)
+	foodItems.Post("/damaged", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.MarkAsDamaged)
+	foodItems.Post("/detect-age", c.Middleware.RequirePermission(domain.PermFoodWrite), c.FoodHandler.DetectFoodAge)
 }
@@ -19,15 +19,21 @@ func (m *middleware) AuthMiddleware(jwtService jwtService.JWTService) fiber.Hand
 		}
 		authHeader = strings.Replace(authHeader, "Bearer ", "", -1)
 
-		userId, userRole, err := jwtService.GetUserIDByToken(authHeader)
+		claims, err := jwtService.ParseTokenUser(authHeader)
 		if err != nil {
 			return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedProcessRequest, err)
 		}
-		if userId == "" {
+		// tokens issued before sessions existed carry no sid and cannot be revoked
+		if claims.SessionID == "" {
+			return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedProcessRequest, domain.ErrTokenInvalid)
+		}
+		if err := m.sessionService.ValidateSession(c.Context(), claims.SessionID, claims.UserID); err != nil {
 			return presenters.ErrorResponse(c, fiber.StatusUnauthorized, domain.MessageFailedProcessRequest, err)
 		}
-		c.Locals("user_id", userId)
-		c.Locals("role", userRole)
+		c.Locals("user_id", claims.UserID)
+		c.Locals("role", claims.Role)
+		c.Locals("session_id", claims.SessionID)
+		c.Locals("amr", claims.AMR)
 		c.Locals("token", authHeader)
 		return c.Next()
 	}
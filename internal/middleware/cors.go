@@ -9,7 +9,7 @@ func (m *middleware) CORSMiddleware() fiber.Handler {
 		c.Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE, PATCH")
 		c.Set("Access-Control-Allow-Headers", "Origin, Content-Type, api_key, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
 		c.Set("Access-Control-Allow-Credentials", "true")
-		c.Set("Access-Control-Expose-Headers", "Content-Length")
+		c.Set("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
 		c.Set("Cache-Control", "no-cache")
 
 		if c.Method() == "OPTIONS" {
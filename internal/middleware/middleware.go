@@ -2,19 +2,36 @@ package middleware
 
 import (
 	"Go-Starter-Template/pkg/jwt"
+	"Go-Starter-Template/pkg/mfa"
+	"Go-Starter-Template/pkg/rbac"
+	"Go-Starter-Template/pkg/session"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
 type (
 	Middleware interface {
 		AuthMiddleware(jwtService jwt.JWTService) fiber.Handler
 		CORSMiddleware() fiber.Handler
+		RequestContext() fiber.Handler
 		OnlyAllow(allow string) fiber.Handler
+		RequireMFA() fiber.Handler
+		RequirePermission(permission string) fiber.Handler
+		RateLimit(bucket string, max int, window time.Duration) fiber.Handler
 	}
 	middleware struct {
+		sessionService session.SessionService
+		mfaService     mfa.MFAService
+		rbacService    rbac.RBACService
+		limiterStorage fiber.Storage
 	}
 )
 
-func NewMiddleware() Middleware {
-	return &middleware{}
+func NewMiddleware(sessionService session.SessionService, mfaService mfa.MFAService, rbacService rbac.RBACService, limiterStorage fiber.Storage) Middleware {
+	return &middleware{
+		sessionService: sessionService,
+		mfaService:     mfaService,
+		rbacService:    rbacService,
+		limiterStorage: limiterStorage,
+	}
 }
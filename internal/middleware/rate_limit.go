@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"time"
+)
+
+// RateLimit gives a route its own per client address bucket, counted in the
+// shared limiter storage so the limit holds across instances.
+func (m *middleware) RateLimit(bucket string, max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		Storage:    m.limiterStorage,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return bucket + ":" + c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return presenters.ErrorResponse(c, fiber.StatusTooManyRequests, domain.MessageFailedTooManyRequest, errors.New(domain.MessageFailedTooManyRequest))
+		},
+	})
+}
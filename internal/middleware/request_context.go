@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// RequestContext stores the client address and user agent in Locals, where
+// services such as the audit log read them back from the request context.
+func (m *middleware) RequestContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// fiber reuses header buffers once the handler returns
+		c.Locals("ip_address", strings.Clone(c.IP()))
+		c.Locals("user_agent", strings.Clone(c.Get(fiber.HeaderUserAgent)))
+		return c.Next()
+	}
+}
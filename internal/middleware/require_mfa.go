@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+)
+
+// RequireMFA rejects sessions that did not pass a second factor when the MFA
+// policy of any of the caller's roles, assigned or implicit, requires one. It
+// must run after AuthMiddleware.
+func (m *middleware) RequireMFA() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+		roles, err := m.rbacService.GetEffectiveRoles(c.Context(), userID)
+		if err != nil {
+			return presenters.ErrorResponse(c, fiber.StatusInternalServerError, domain.MessageFailedProcessRequest, err)
+		}
+		required, err := m.mfaService.IsRequired(c.Context(), roles)
+		if err != nil {
+			return presenters.ErrorResponse(c, fiber.StatusInternalServerError, domain.MessageFailedProcessRequest, err)
+		}
+		if !required {
+			return c.Next()
+		}
+
+		amr, _ := c.Locals("amr").([]string)
+		if !slices.Contains(amr, domain.AMROTP) {
+			return presenters.ErrorResponse(c, fiber.StatusForbidden, domain.MesaageUserNotAllowed, domain.ErrMFARequired)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/api/presenters"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission rejects callers whose roles do not grant permission. It
+// must run after AuthMiddleware.
+func (m *middleware) RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("user_id").(string)
+		allowed, err := m.rbacService.HasPermission(c.Context(), userID, permission)
+		if err != nil {
+			return presenters.ErrorResponse(c, fiber.StatusInternalServerError, domain.MessageFailedProcessRequest, err)
+		}
+		if !allowed {
+			return presenters.ErrorResponse(c, fiber.StatusForbidden, domain.MesaageUserNotAllowed, domain.ErrMissingPermission)
+		}
+		return c.Next()
+	}
+}
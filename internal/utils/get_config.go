@@ -4,6 +4,8 @@ import (
 	"gopkg.in/yaml.v2"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -18,6 +20,50 @@ type Config struct {
 	JWTSecret string `yaml:"JWT_SECRET"`
 	AESKey    string `yaml:"AES_KEY"`
 
+	// JWTKeyFiles is a comma separated list of PEM files, the file name
+	// without extension is used as the key id.
+	JWTKeyFiles  string `yaml:"JWT_KEY_FILES"`
+	JWTActiveKID string `yaml:"JWT_ACTIVE_KID"`
+
+	// Password policy
+	PasswordMinLength     string `yaml:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength     string `yaml:"PASSWORD_MAX_LENGTH"`
+	PasswordRequireUpper  string `yaml:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower  string `yaml:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit  string `yaml:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol string `yaml:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordRejectCommon  string `yaml:"PASSWORD_REJECT_COMMON"`
+	PasswordHistory       string `yaml:"PASSWORD_HISTORY"`
+
+	// Session configuration
+	AccessTokenTTL  string `yaml:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL string `yaml:"REFRESH_TOKEN_TTL"`
+	RBACCacheTTL    string `yaml:"RBAC_CACHE_TTL"`
+
+	// Data export configuration
+	DataExportLinkTTL   string `yaml:"DATA_EXPORT_LINK_TTL"`
+	DataExportRetention string `yaml:"DATA_EXPORT_RETENTION"`
+
+	// Food trash configuration
+	FoodTrashRetention     string `yaml:"FOOD_TRASH_RETENTION"`
+	ReceiptDuplicateWindow string `yaml:"RECEIPT_DUPLICATE_WINDOW"`
+
+	// Image processing configuration
+	ImageMaxSize     string `yaml:"IMAGE_MAX_SIZE"`
+	ImageVisionSize  string `yaml:"IMAGE_VISION_SIZE"`
+	ImageMaxPixels   string `yaml:"IMAGE_MAX_PIXELS"`
+	ImageJPEGQuality string `yaml:"IMAGE_JPEG_QUALITY"`
+	UploadMaxPages   string `yaml:"UPLOAD_MAX_PAGES"`
+
+	// Account deletion configuration
+	AccountDeletionGrace string `yaml:"ACCOUNT_DELETION_GRACE"`
+
+	// Google sign in configuration
+	GoogleClientID     string `yaml:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `yaml:"GOOGLE_CLIENT_SECRET"`
+	GoogleRedirectURL  string `yaml:"GOOGLE_REDIRECT_URL"`
+	GoogleIssuerURL    string `yaml:"GOOGLE_ISSUER_URL"`
+
 	// Mailing configuration
 	AppURL           string `yaml:"APP_URL"`
 	SMTPHost         string `yaml:"SMTP_HOST"`
@@ -31,12 +77,34 @@ type Config struct {
 	ServerKey string `yaml:"SERVER_KEY"`
 	IsProd    bool   `yaml:"IsProd"`
 
+	MidtransAPIURL string `yaml:"MIDTRANS_API_URL"`
+
+	// Xendit configuration
+	XenditSecretKey     string `yaml:"XENDIT_SECRET_KEY"`
+	XenditCallbackToken string `yaml:"XENDIT_CALLBACK_TOKEN"`
+	XenditAPIURL        string `yaml:"XENDIT_API_URL"`
+
+	// Payment configuration
+	PaymentDefaultGateway    string `yaml:"PAYMENT_DEFAULT_GATEWAY"`
+	PaymentReconcileInterval string `yaml:"PAYMENT_RECONCILE_INTERVAL"`
+	PaymentPendingAfter      string `yaml:"PAYMENT_PENDING_AFTER"`
+	PaymentExpireAfter       string `yaml:"PAYMENT_EXPIRE_AFTER"`
+
 	// AWS S3 configuration
 	AWSS3Bucket  string `yaml:"AWS_S3_BUCKET"`
 	AWSS3Region  string `yaml:"AWS_S3_REGION"`
 	AWSAccessKey string `yaml:"AWS_ACCESS_KEY"`
 	AWSSecretKey string `yaml:"AWS_SECRET_KEY"`
 
+	// Storage configuration
+	StorageDriver     string `yaml:"STORAGE_DRIVER"`
+	StorageEndpoint   string `yaml:"STORAGE_ENDPOINT"`
+	StoragePathStyle  string `yaml:"STORAGE_PATH_STYLE"`
+	StoragePublicURL  string `yaml:"STORAGE_PUBLIC_URL"`
+	StorageLocalDir   string `yaml:"STORAGE_LOCAL_DIR"`
+	StorageSigningKey string `yaml:"STORAGE_SIGNING_KEY"`
+	StorageLinkTTL    string `yaml:"STORAGE_LINK_TTL"`
+
 	// Gemini API configuration
 	GeminiAPIKey string `yaml:"GEMINI_API_KEY"`
 	GeminiModel  string `yaml:"GEMINI_MODEL"`
@@ -66,10 +134,6 @@ func LoadConfig() {
 	os.Setenv("SERVER_KEY", config.ServerKey)
 	os.Setenv("CLIENT_KEY", config.ClientKey)
 	os.Setenv("IS_PROD", getBoolString(config.IsProd))
-	os.Setenv("AWS_S3_BUCKET", config.AWSS3Bucket)
-	os.Setenv("AWS_S3_REGION", config.AWSS3Region)
-	os.Setenv("AWS_ACCESS_KEY", config.AWSAccessKey)
-	os.Setenv("AWS_SECRET_KEY", config.AWSSecretKey)
 	os.Setenv("GEMINI_API_KEY", config.GeminiAPIKey)
 	os.Setenv("AI_MODEL_URL", config.AIModelURL)
 }
@@ -97,6 +161,60 @@ func GetConfig(key string) string {
 		return config.JWTSecret
 	case "AES_KEY":
 		return config.AESKey
+	case "JWT_KEY_FILES":
+		return config.JWTKeyFiles
+	case "JWT_ACTIVE_KID":
+		return config.JWTActiveKID
+	case "PASSWORD_MIN_LENGTH":
+		return config.PasswordMinLength
+	case "PASSWORD_MAX_LENGTH":
+		return config.PasswordMaxLength
+	case "PASSWORD_REQUIRE_UPPER":
+		return config.PasswordRequireUpper
+	case "PASSWORD_REQUIRE_LOWER":
+		return config.PasswordRequireLower
+	case "PASSWORD_REQUIRE_DIGIT":
+		return config.PasswordRequireDigit
+	case "PASSWORD_REQUIRE_SYMBOL":
+		return config.PasswordRequireSymbol
+	case "PASSWORD_REJECT_COMMON":
+		return config.PasswordRejectCommon
+	case "PASSWORD_HISTORY":
+		return config.PasswordHistory
+	case "ACCESS_TOKEN_TTL":
+		return config.AccessTokenTTL
+	case "REFRESH_TOKEN_TTL":
+		return config.RefreshTokenTTL
+	case "RBAC_CACHE_TTL":
+		return config.RBACCacheTTL
+	case "DATA_EXPORT_LINK_TTL":
+		return config.DataExportLinkTTL
+	case "DATA_EXPORT_RETENTION":
+		return config.DataExportRetention
+	case "FOOD_TRASH_RETENTION":
+		return config.FoodTrashRetention
+	case "RECEIPT_DUPLICATE_WINDOW":
+		return config.ReceiptDuplicateWindow
+	case "IMAGE_MAX_SIZE":
+		return config.ImageMaxSize
+	case "IMAGE_VISION_SIZE":
+		return config.ImageVisionSize
+	case "IMAGE_MAX_PIXELS":
+		return config.ImageMaxPixels
+	case "IMAGE_JPEG_QUALITY":
+		return config.ImageJPEGQuality
+	case "UPLOAD_MAX_PAGES":
+		return config.UploadMaxPages
+	case "ACCOUNT_DELETION_GRACE":
+		return config.AccountDeletionGrace
+	case "GOOGLE_CLIENT_ID":
+		return config.GoogleClientID
+	case "GOOGLE_CLIENT_SECRET":
+		return config.GoogleClientSecret
+	case "GOOGLE_REDIRECT_URL":
+		return config.GoogleRedirectURL
+	case "GOOGLE_ISSUER_URL":
+		return config.GoogleIssuerURL
 	case "APP_URL":
 		return config.AppURL
 	case "SMTP_HOST":
@@ -118,6 +236,22 @@ func GetConfig(key string) string {
 			return "true"
 		}
 		return "false"
+	case "MIDTRANS_API_URL":
+		return config.MidtransAPIURL
+	case "XENDIT_SECRET_KEY":
+		return config.XenditSecretKey
+	case "XENDIT_CALLBACK_TOKEN":
+		return config.XenditCallbackToken
+	case "XENDIT_API_URL":
+		return config.XenditAPIURL
+	case "PAYMENT_DEFAULT_GATEWAY":
+		return config.PaymentDefaultGateway
+	case "PAYMENT_RECONCILE_INTERVAL":
+		return config.PaymentReconcileInterval
+	case "PAYMENT_PENDING_AFTER":
+		return config.PaymentPendingAfter
+	case "PAYMENT_EXPIRE_AFTER":
+		return config.PaymentExpireAfter
 	case "AWS_S3_BUCKET":
 		return config.AWSS3Bucket
 	case "AWS_S3_REGION":
@@ -126,6 +260,20 @@ func GetConfig(key string) string {
 		return config.AWSAccessKey
 	case "AWS_SECRET_KEY":
 		return config.AWSSecretKey
+	case "STORAGE_DRIVER":
+		return config.StorageDriver
+	case "STORAGE_ENDPOINT":
+		return config.StorageEndpoint
+	case "STORAGE_PATH_STYLE":
+		return config.StoragePathStyle
+	case "STORAGE_PUBLIC_URL":
+		return config.StoragePublicURL
+	case "STORAGE_LOCAL_DIR":
+		return config.StorageLocalDir
+	case "STORAGE_SIGNING_KEY":
+		return config.StorageSigningKey
+	case "STORAGE_LINK_TTL":
+		return config.StorageLinkTTL
 	case "GEMINI_API_KEY":
 		return config.GeminiAPIKey
 	case "GEMINI_MODEL":
@@ -136,3 +284,44 @@ func GetConfig(key string) string {
 		return ""
 	}
 }
+
+// GetDurationConfig parses a duration such as "15m" from the config, falling
+// back when the key is empty or invalid.
+func GetDurationConfig(key string, fallback time.Duration) time.Duration {
+	value := GetConfig(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid duration %q for %s, using %s", value, key, fallback)
+		return fallback
+	}
+	return d
+}
+
+func GetIntConfig(key string, fallback int) int {
+	value := GetConfig(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid number %q for %s, using %d", value, key, fallback)
+		return fallback
+	}
+	return n
+}
+
+func GetBoolConfig(key string, fallback bool) bool {
+	value := GetConfig(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("invalid boolean %q for %s, using %t", value, key, fallback)
+		return fallback
+	}
+	return b
+}
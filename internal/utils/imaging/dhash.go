@@ -0,0 +1,26 @@
+package imaging
+
+import (
+	"golang.org/x/image/draw"
+	"image"
+)
+
+// dHash is the difference hash of img: each bit tells whether a pixel of a
+// 9x8 grayscale thumbnail is brighter than its right neighbour. Photos of
+// the same thing differ in a few bits at most, compare with bits.OnesCount64
+// of the XOR.
+func dHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
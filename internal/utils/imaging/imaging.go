@@ -0,0 +1,165 @@
+package imaging
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"bytes"
+	"errors"
+	"github.com/gen2brain/heic"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// ContentType is what Process encodes to.
+const ContentType = "image/jpeg"
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image format")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Variants are the thumbnails stored next to an image, see VariantKey.
+var Variants = []Variant{
+	{Name: "sm", Size: 256},
+	{Name: "md", Size: 768},
+}
+
+func init() {
+	// the heic package only registers the "heic" brand, phones also write these
+	for _, brand := range []string{"heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1"} {
+		image.RegisterFormat("heic", "????ftyp"+brand, heic.Decode, heic.DecodeConfig)
+	}
+}
+
+type (
+	// Config sizes are the longest side in pixels, images are only ever
+	// scaled down.
+	Config struct {
+		// MaxSize bounds the stored image.
+		MaxSize int
+		// VisionSize bounds the copy sent to the vision provider.
+		VisionSize int
+		// MaxPixels rejects an image before it is decoded, a small file can
+		// declare huge dimensions.
+		MaxPixels int
+		Quality   int
+		Variants  []Variant
+	}
+
+	Variant struct {
+		Name string
+		Size int
+	}
+
+	// Image is an encoded JPEG.
+	Image struct {
+		Data   []byte
+		Width  int
+		Height int
+	}
+
+	Result struct {
+		Image    Image
+		Vision   Image
+		Variants map[string]Image
+		// DHash is a perceptual hash of the image, for finding the same
+		// picture taken or encoded again.
+		DHash uint64
+	}
+)
+
+func LoadConfig() Config {
+	return Config{
+		MaxSize:    utils.GetIntConfig("IMAGE_MAX_SIZE", 3072),
+		VisionSize: utils.GetIntConfig("IMAGE_VISION_SIZE", 2048),
+		MaxPixels:  utils.GetIntConfig("IMAGE_MAX_PIXELS", 40_000_000),
+		Quality:    utils.GetIntConfig("IMAGE_JPEG_QUALITY", 85),
+		Variants:   Variants,
+	}
+}
+
+// Process decodes a JPEG, PNG, WebP or HEIC image, turns it upright and
+// re-encodes it as JPEG at the sizes in config. Nothing but the pixels is
+// carried over, so EXIF and with it the GPS position is gone.
+func Process(data []byte, config Config) (Result, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, ErrUnsupportedImage
+	}
+	if config.MaxPixels > 0 && cfg.Width*cfg.Height > config.MaxPixels {
+		return Result{}, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+	img = fit(img, config.MaxSize)
+	// libheif applies the HEIC rotation itself, JPEG leaves it to the reader
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+
+	result := Result{Variants: make(map[string]Image, len(config.Variants)), DHash: dHash(img)}
+	if result.Image, err = encode(img, config.Quality); err != nil {
+		return Result{}, err
+	}
+	if result.Vision, err = encode(fit(img, config.VisionSize), config.Quality); err != nil {
+		return Result{}, err
+	}
+	for _, variant := range config.Variants {
+		if result.Variants[variant.Name], err = encode(fit(img, variant.Size), config.Quality); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// VariantKey is where the variant name of the image at objectKey is stored.
+func VariantKey(objectKey, name string) string {
+	return objectKey + "_" + name
+}
+
+// VariantKeys lists the keys of every variant objectKey may have, for
+// cleaning up. Deleting a key that was never written is not an error.
+func VariantKeys(objectKey string) []string {
+	keys := make([]string, 0, len(Variants))
+	for _, variant := range Variants {
+		keys = append(keys, VariantKey(objectKey, variant.Name))
+	}
+	return keys
+}
+
+// fit scales img down so its longest side is at most size.
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return img
+	}
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func encode(img image.Image, quality int) (Image, error) {
+	// JPEG has no alpha, transparent parts would come out black
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return Image{}, err
+	}
+	return Image{Data: buf.Bytes(), Width: b.Dx(), Height: b.Dy()}, nil
+}
@@ -0,0 +1,124 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	KindInvoice = "INVOICE"
+	KindReceipt = "RECEIPT"
+)
+
+type (
+	Line struct {
+		Description string
+		Quantity    int
+		UnitPrice   int64
+	}
+
+	Document struct {
+		Kind          string
+		Number        string
+		IssuedAt      time.Time
+		PaidAt        *time.Time
+		Status        string
+		CustomerName  string
+		CustomerEmail string
+		Lines         []Line
+	}
+)
+
+func (d Document) Total() int64 {
+	var total int64
+	for _, line := range d.Lines {
+		total += int64(line.Quantity) * line.UnitPrice
+	}
+	return total
+}
+
+// Render draws the document as a single A4 page and returns the PDF bytes.
+func Render(doc Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("FOODIA %s %s", doc.Kind, doc.Number), true)
+	pdf.SetAuthor("FOODIA", true)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 10, "FOODIA", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, doc.Kind, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "", 10)
+	writeField(pdf, "Number", doc.Number)
+	writeField(pdf, "Issued", doc.IssuedAt.Format("02 Jan 2006 15:04"))
+	if doc.PaidAt != nil {
+		writeField(pdf, "Paid", doc.PaidAt.Format("02 Jan 2006 15:04"))
+	}
+	writeField(pdf, "Status", strings.ToUpper(doc.Status))
+	writeField(pdf, "Billed to", doc.CustomerName)
+	writeField(pdf, "Email", doc.CustomerEmail)
+	pdf.Ln(6)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.CellFormat(100, 8, "Description", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(20, 8, "Qty", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(35, 8, "Unit price", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(35, 8, "Amount", "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range doc.Lines {
+		pdf.CellFormat(100, 8, line.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 8, fmt.Sprintf("%d", line.Quantity), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(35, 8, FormatRupiah(line.UnitPrice), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(35, 8, FormatRupiah(int64(line.Quantity)*line.UnitPrice), "1", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(155, 8, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(35, 8, FormatRupiah(doc.Total()), "1", 1, "R", false, 0, "")
+
+	pdf.Ln(10)
+	pdf.SetFont("Helvetica", "I", 9)
+	if doc.Kind == KindReceipt {
+		pdf.MultiCell(0, 5, "Thank you for your payment. This receipt is generated electronically and is valid without a signature.", "", "L", false)
+	} else {
+		pdf.MultiCell(0, 5, "This invoice is generated electronically and is valid without a signature.", "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeField(pdf *fpdf.Fpdf, label, value string) {
+	pdf.CellFormat(30, 6, label, "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, ": "+value, "", 1, "L", false, 0, "")
+}
+
+// FormatRupiah formats 150000 as "Rp 150.000".
+func FormatRupiah(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%d", amount)
+	var out strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteRune('.')
+		}
+		out.WriteRune(r)
+	}
+	return "Rp " + sign + out.String()
+}
@@ -0,0 +1,94 @@
+package itemmatch
+
+import (
+	"sort"
+	"strings"
+)
+
+// MinSimilarity is how alike two names have to be, see Similarity, to be
+// taken for the same item spelled differently.
+const MinSimilarity = 0.5
+
+// Normalize folds the differences in how a name is written that do not
+// make it another item: case and spacing.
+func Normalize(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Similarity is 1 for names equal after Normalize, going down to 0 the
+// more characters have to change to turn one into the other.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(Normalize(a)), []rune(Normalize(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(distance(ra, rb))/float64(longest)
+}
+
+// Match pairs the names in a with those in b, each used once, and returns
+// the index in b for every index in a that found one. Equal names pair up
+// first, then the most similar of the rest down to MinSimilarity.
+func Match(a, b []string) map[int]int {
+	pairs := make(map[int]int)
+	used := make(map[int]bool)
+	for i, name := range a {
+		for j, other := range b {
+			if !used[j] && Normalize(name) == Normalize(other) {
+				pairs[i] = j
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	type candidate struct {
+		i, j       int
+		similarity float64
+	}
+	var candidates []candidate
+	for i, name := range a {
+		if _, ok := pairs[i]; ok {
+			continue
+		}
+		for j, other := range b {
+			if used[j] {
+				continue
+			}
+			if similarity := Similarity(name, other); similarity >= MinSimilarity {
+				candidates = append(candidates, candidate{i, j, similarity})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(x, y int) bool {
+		return candidates[x].similarity > candidates[y].similarity
+	})
+	for _, c := range candidates {
+		if _, ok := pairs[c.i]; ok || used[c.j] {
+			continue
+		}
+		pairs[c.i] = c.j
+		used[c.j] = true
+	}
+	return pairs
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			diagonal, row[j] = row[j], min(row[j]+1, row[j-1]+1, diagonal+cost)
+		}
+	}
+	return row[len(b)]
+}
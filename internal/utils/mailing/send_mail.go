@@ -3,9 +3,15 @@ package mailing
 import (
 	"Go-Starter-Template/internal/utils"
 	"gopkg.in/gomail.v2"
+	"io"
 	"strconv"
 )
 
+type Attachment struct {
+	Filename string
+	Data     []byte
+}
+
 type MailConfig struct {
 	AppURL       string
 	SMTPHost     string
@@ -27,6 +33,10 @@ func LoadMailConfig() MailConfig {
 }
 
 func SendMail(toEmail string, subject string, body string) error {
+	return SendMailWithAttachments(toEmail, subject, body)
+}
+
+func SendMailWithAttachments(toEmail string, subject string, body string, attachments ...Attachment) error {
 	emailConfig := LoadMailConfig()
 
 	mailer := gomail.NewMessage()
@@ -34,6 +44,13 @@ func SendMail(toEmail string, subject string, body string) error {
 	mailer.SetHeader("To", toEmail)
 	mailer.SetHeader("Subject", subject)
 	mailer.SetBody("text/html", body)
+	for _, attachment := range attachments {
+		data := attachment.Data
+		mailer.Attach(attachment.Filename, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
 	port, err := strconv.Atoi(emailConfig.SMTPPort)
 	if err != nil {
 		return err
@@ -0,0 +1,166 @@
+package oauth
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"sync"
+)
+
+const (
+	ProviderGoogle = "google"
+
+	googleIssuerURL = "https://accounts.google.com"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+	ErrNonceMismatch   = errors.New("id token nonce mismatch")
+	ErrMissingIDToken  = errors.New("token response has no id_token")
+)
+
+type (
+	// ProviderConfig points at any OpenID Connect issuer, endpoints are read
+	// from its discovery document so a local mock server works as well.
+	ProviderConfig struct {
+		Name         string
+		IssuerURL    string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+	}
+
+	// Identity is the subset of ID token claims used to find or create a user.
+	Identity struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+
+	Provider interface {
+		Name() string
+		AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+		Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error)
+	}
+
+	Providers map[string]Provider
+
+	oidcProvider struct {
+		config ProviderConfig
+
+		mu       sync.Mutex
+		oauth2   *oauth2.Config
+		verifier *oidc.IDTokenVerifier
+	}
+)
+
+func NewProviders(providers ...Provider) Providers {
+	registry := make(Providers, len(providers))
+	for _, provider := range providers {
+		registry[provider.Name()] = provider
+	}
+	return registry
+}
+
+func (p Providers) Get(name string) (Provider, error) {
+	provider, ok := p[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return provider, nil
+}
+
+func LoadGoogleConfig() ProviderConfig {
+	issuerURL := utils.GetConfig("GOOGLE_ISSUER_URL")
+	if issuerURL == "" {
+		issuerURL = googleIssuerURL
+	}
+
+	return ProviderConfig{
+		Name:         ProviderGoogle,
+		IssuerURL:    issuerURL,
+		ClientID:     utils.GetConfig("GOOGLE_CLIENT_ID"),
+		ClientSecret: utils.GetConfig("GOOGLE_CLIENT_SECRET"),
+		RedirectURL:  utils.GetConfig("GOOGLE_REDIRECT_URL"),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+}
+
+// NewOIDCProvider returns a provider that fetches the discovery document on
+// first use, so the API still starts when the issuer is unreachable.
+func NewOIDCProvider(config ProviderConfig) Provider {
+	return &oidcProvider{config: config}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (Identity, error) {
+	config, verifier, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return Identity{}, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, ErrMissingIDToken
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, err
+	}
+	if idToken.Nonce != nonce {
+		return Identity{}, ErrNonceMismatch
+	}
+
+	var identity Identity
+	if err := idToken.Claims(&identity); err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.verifier, nil
+	}
+
+	// the provider keeps using this context to refresh its key set
+	provider, err := oidc.NewProvider(context.WithoutCancel(ctx), p.config.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s discovery: %w", p.config.Name, err)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       p.config.Scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.config.ClientID})
+	return p.oauth2, p.verifier, nil
+}
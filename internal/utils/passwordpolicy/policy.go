@@ -0,0 +1,129 @@
+package passwordpolicy
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"bufio"
+	_ "embed"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = parseList(commonPasswordList)
+
+type (
+	// Policy is applied whenever a password is chosen: on register, reset
+	// and change. HistorySize is enforced by the caller, which owns the
+	// stored hashes.
+	Policy struct {
+		MinLength     int
+		MaxLength     int
+		RequireUpper  bool
+		RequireLower  bool
+		RequireDigit  bool
+		RequireSymbol bool
+		RejectCommon  bool
+		HistorySize   int
+	}
+
+	// Violation lists every rule a password broke, so the client can show
+	// them all at once.
+	Violation struct {
+		Problems []string
+	}
+)
+
+func (v *Violation) Error() string {
+	return "password does not meet the policy: " + strings.Join(v.Problems, ", ")
+}
+
+func LoadPolicy() Policy {
+	return Policy{
+		MinLength:     utils.GetIntConfig("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     utils.GetIntConfig("PASSWORD_MAX_LENGTH", 72), // bcrypt ignores anything longer
+		RequireUpper:  utils.GetBoolConfig("PASSWORD_REQUIRE_UPPER", false),
+		RequireLower:  utils.GetBoolConfig("PASSWORD_REQUIRE_LOWER", false),
+		RequireDigit:  utils.GetBoolConfig("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: utils.GetBoolConfig("PASSWORD_REQUIRE_SYMBOL", false),
+		RejectCommon:  utils.GetBoolConfig("PASSWORD_REJECT_COMMON", true),
+		HistorySize:   utils.GetIntConfig("PASSWORD_HISTORY", 5),
+	}
+}
+
+// Validate checks a candidate password. userInputs are values such as the
+// email and username that must not be the password.
+func (p Policy) Validate(password string, userInputs ...string) error {
+	var problems []string
+
+	length := len([]rune(password))
+	if length < p.MinLength {
+		problems = append(problems, "must be at least "+strconv.Itoa(p.MinLength)+" characters")
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		problems = append(problems, "must be at most "+strconv.Itoa(p.MaxLength)+" bytes")
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	normalized := strings.ToLower(password)
+	if p.RejectCommon {
+		if _, ok := commonPasswords[normalized]; ok {
+			problems = append(problems, "is too common")
+		}
+	}
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if local, _, ok := strings.Cut(input, "@"); ok {
+			input = local
+		}
+		if len(input) >= 3 && strings.Contains(normalized, input) {
+			problems = append(problems, "must not contain your name or email")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return &Violation{Problems: problems}
+	}
+	return nil
+}
+
+func parseList(list string) map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
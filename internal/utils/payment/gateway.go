@@ -0,0 +1,112 @@
+package payment
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	GatewayMidtrans = "midtrans"
+	GatewayXendit   = "xendit"
+)
+
+var (
+	ErrUnknownGateway         = errors.New("unknown payment gateway")
+	ErrInvalidWebhook         = errors.New("invalid webhook signature")
+	ErrGatewayPaymentNotFound = errors.New("payment not found on gateway")
+)
+
+type (
+	CheckoutRequest struct {
+		OrderID       string
+		Amount        int64
+		Description   string
+		CustomerName  string
+		CustomerEmail string
+		// ExpiresIn is how long the checkout can be paid, counted from its
+		// creation.
+		ExpiresIn time.Duration
+	}
+
+	CheckoutResult struct {
+		// Reference is the gateway side identifier, it equals the order id
+		// for Midtrans and is the invoice id for Xendit.
+		Reference   string
+		RedirectURL string
+	}
+
+	// PaymentStatus is a gateway notification or status lookup translated to
+	// one of the domain.TransactionStatus* values.
+	PaymentStatus struct {
+		OrderID   string
+		Reference string
+		Status    string
+		RawStatus string
+		Amount    int64
+	}
+
+	RefundRequest struct {
+		OrderID   string
+		Reference string
+		RefundKey string
+		Amount    int64
+		Reason    string
+	}
+
+	RefundResult struct {
+		RefundID string
+		Amount   int64
+	}
+
+	PaymentGateway interface {
+		Name() string
+		CreateCheckout(ctx context.Context, req CheckoutRequest) (CheckoutResult, error)
+		VerifyWebhook(header http.Header, body []byte) (PaymentStatus, error)
+		QueryStatus(ctx context.Context, orderID, reference string) (PaymentStatus, error)
+		Refund(ctx context.Context, req RefundRequest) (RefundResult, error)
+		Cancel(ctx context.Context, orderID, reference string) error
+	}
+
+	Gateways map[string]PaymentGateway
+
+	PaymentConfig struct {
+		DefaultGateway    string
+		ReconcileInterval time.Duration
+		PendingAfter      time.Duration
+		ExpireAfter       time.Duration
+	}
+)
+
+func NewGateways(gateways ...PaymentGateway) Gateways {
+	registry := make(Gateways, len(gateways))
+	for _, gateway := range gateways {
+		registry[gateway.Name()] = gateway
+	}
+	return registry
+}
+
+func (g Gateways) Get(name string) (PaymentGateway, error) {
+	gateway, ok := g[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGateway, name)
+	}
+	return gateway, nil
+}
+
+func LoadPaymentConfig() PaymentConfig {
+	defaultGateway := utils.GetConfig("PAYMENT_DEFAULT_GATEWAY")
+	if defaultGateway == "" {
+		defaultGateway = GatewayMidtrans
+	}
+
+	return PaymentConfig{
+		DefaultGateway:    defaultGateway,
+		ReconcileInterval: utils.GetDurationConfig("PAYMENT_RECONCILE_INTERVAL", 5*time.Minute),
+		PendingAfter:      utils.GetDurationConfig("PAYMENT_PENDING_AFTER", 15*time.Minute),
+		ExpireAfter:       utils.GetDurationConfig("PAYMENT_EXPIRE_AFTER", 24*time.Hour),
+	}
+}
@@ -1,31 +1,62 @@
 package payment
 
 import (
+	"Go-Starter-Template/domain"
 	"Go-Starter-Template/entities"
 	"Go-Starter-Template/internal/utils"
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
 )
 
+const (
+	midtransSandboxAPIURL    = "https://api.sandbox.midtrans.com"
+	midtransProductionAPIURL = "https://api.midtrans.com"
+)
+
 type MidtransConfig struct {
 	ClientKey string
 	ServerKey string
 	IsProd    bool
+
+	// APIURL is the Core API base url, it can point to a fake server in tests.
+	APIURL string
 }
 
 func LoadMidtransConfig() MidtransConfig {
 	isProd := os.Getenv("IS_PROD")
 	prodMode := isProd == "true"
 
+	apiURL := utils.GetConfig("MIDTRANS_API_URL")
+	if apiURL == "" {
+		apiURL = midtransSandboxAPIURL
+		if prodMode {
+			apiURL = midtransProductionAPIURL
+		}
+	}
+
 	return MidtransConfig{
 		ClientKey: utils.GetConfig("CLIENT_KEY"),
 		ServerKey: utils.GetConfig("SERVER_KEY"),
 		IsProd:    prodMode,
+		APIURL:    strings.TrimSuffix(apiURL, "/"),
 	}
 }
 
@@ -51,7 +82,7 @@ func LogTransaction(transaction entities.Transaction) {
 
 	// Log the payment info
 	logger.Printf(
-		"✅ [PAID] Invoice: %s | UserID: %d | Status: %s | Time: %s",
+		"✅ [PAID] Invoice: %s | UserID: %s | Status: %s | Time: %s",
 		transaction.Invoice,
 		transaction.UserID,
 		transaction.Status,
@@ -61,9 +92,7 @@ func LogTransaction(transaction entities.Transaction) {
 	fmt.Println("Log entry successfully written")
 }
 
-func NewMidtransClient() snap.Client {
-	midtransConfig := LoadMidtransConfig()
-
+func NewMidtransClient(midtransConfig MidtransConfig) snap.Client {
 	var client snap.Client
 	client.New(midtransConfig.ServerKey, midtrans.Sandbox)
 	if midtransConfig.IsProd {
@@ -71,3 +100,212 @@ func NewMidtransClient() snap.Client {
 	}
 	return client
 }
+
+type (
+	midtransGateway struct {
+		config     MidtransConfig
+		snapClient snap.Client
+		httpClient *http.Client
+	}
+
+	midtransNotification struct {
+		TransactionStatus string `json:"transaction_status"`
+		OrderID           string `json:"order_id"`
+		GrossAmount       string `json:"gross_amount"`
+		FraudStatus       string `json:"fraud_status"`
+		StatusCode        string `json:"status_code"`
+		StatusMessage     string `json:"status_message"`
+		SignatureKey      string `json:"signature_key"`
+	}
+)
+
+func NewMidtransGateway(midtransConfig MidtransConfig) PaymentGateway {
+	return &midtransGateway{
+		config:     midtransConfig,
+		snapClient: NewMidtransClient(midtransConfig),
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (g *midtransGateway) Name() string {
+	return GatewayMidtrans
+}
+
+func (g *midtransGateway) CreateCheckout(ctx context.Context, req CheckoutRequest) (CheckoutResult, error) {
+	snapResp, snapErr := g.snapClient.CreateTransaction(&snap.Request{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  req.OrderID,
+			GrossAmt: req.Amount,
+		},
+		CustomerDetail: &midtrans.CustomerDetails{
+			FName: req.CustomerName,
+			Email: req.CustomerEmail,
+		},
+		// without a start time the expiry counts from when the customer
+		// picks a payment method, not from now
+		Expiry: &snap.ExpiryDetails{
+			StartTime: time.Now().Format("2006-01-02 15:04:05 -0700"),
+			Unit:      "minute",
+			Duration:  max(int64(req.ExpiresIn/time.Minute), 1),
+		},
+	})
+	if snapErr != nil {
+		return CheckoutResult{}, snapErr
+	}
+
+	return CheckoutResult{
+		Reference:   req.OrderID,
+		RedirectURL: snapResp.RedirectURL,
+	}, nil
+}
+
+// VerifyWebhook checks signature_key, which Midtrans computes as
+// sha512(order_id + status_code + gross_amount + server_key).
+func (g *midtransGateway) VerifyWebhook(_ http.Header, body []byte) (PaymentStatus, error) {
+	var notification midtransNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return PaymentStatus{}, ErrInvalidWebhook
+	}
+
+	raw := notification.OrderID + notification.StatusCode + notification.GrossAmount + g.config.ServerKey
+	hash := sha512.Sum512([]byte(raw))
+	expected := hex.EncodeToString(hash[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(notification.SignatureKey)) != 1 {
+		return PaymentStatus{}, ErrInvalidWebhook
+	}
+
+	return notification.toPaymentStatus(), nil
+}
+
+// QueryStatus calls GET /v2/{order_id}/status. Midtrans answers unknown orders
+// with HTTP 200 and status_code "404", both map to ErrGatewayPaymentNotFound.
+func (g *midtransGateway) QueryStatus(ctx context.Context, orderID, _ string) (PaymentStatus, error) {
+	var notification midtransNotification
+	endpoint := fmt.Sprintf("/v2/%s/status", url.PathEscape(orderID))
+	if err := g.do(ctx, http.MethodGet, endpoint, nil, &notification); err != nil {
+		return PaymentStatus{}, err
+	}
+	if notification.StatusCode == "404" {
+		return PaymentStatus{}, ErrGatewayPaymentNotFound
+	}
+	return notification.toPaymentStatus(), nil
+}
+
+func (g *midtransGateway) Refund(ctx context.Context, req RefundRequest) (RefundResult, error) {
+	payload := map[string]any{
+		"refund_key": req.RefundKey,
+		"amount":     req.Amount,
+		"reason":     req.Reason,
+	}
+
+	var resp struct {
+		StatusCode    string `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+		RefundKey     string `json:"refund_key"`
+		RefundAmount  string `json:"refund_amount"`
+	}
+	endpoint := fmt.Sprintf("/v2/%s/refund", url.PathEscape(req.OrderID))
+	if err := g.do(ctx, http.MethodPost, endpoint, payload, &resp); err != nil {
+		return RefundResult{}, err
+	}
+	if resp.StatusCode != "200" {
+		return RefundResult{}, fmt.Errorf("midtrans refund failed: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+
+	return RefundResult{
+		RefundID: resp.RefundKey,
+		Amount:   parseAmount(resp.RefundAmount),
+	}, nil
+}
+
+// Cancel expires a pending payment. An order that was never opened in the snap
+// page does not exist on Midtrans yet, so there is nothing to cancel, but its
+// snap link stays payable and a settlement can still arrive for it.
+func (g *midtransGateway) Cancel(ctx context.Context, orderID, _ string) error {
+	var resp midtransNotification
+	endpoint := fmt.Sprintf("/v2/%s/expire", url.PathEscape(orderID))
+	err := g.do(ctx, http.MethodPost, endpoint, nil, &resp)
+	if errors.Is(err, ErrGatewayPaymentNotFound) || resp.StatusCode == "404" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != "200" && resp.StatusCode != "407" {
+		return fmt.Errorf("midtrans cancel failed: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+	return nil
+}
+
+func (g *midtransGateway) do(ctx context.Context, method, endpoint string, payload any, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.config.APIURL+endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.config.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrGatewayPaymentNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("midtrans API error: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (n midtransNotification) toPaymentStatus() PaymentStatus {
+	return PaymentStatus{
+		OrderID:   n.OrderID,
+		Reference: n.OrderID,
+		Status:    midtransStatus(n.TransactionStatus, n.FraudStatus),
+		RawStatus: n.TransactionStatus,
+		Amount:    parseAmount(n.GrossAmount),
+	}
+}
+
+func midtransStatus(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "capture":
+		if fraudStatus == "accept" {
+			return domain.TransactionStatusPaid
+		}
+		return domain.TransactionStatusFraud
+	case "settlement":
+		return domain.TransactionStatusPaid
+	case "deny", "cancel", "expire":
+		return domain.TransactionStatusFailed
+	case "pending":
+		return domain.TransactionStatusPending
+	case "refund":
+		return domain.TransactionStatusRefunded
+	case "partial_refund":
+		return domain.TransactionStatusPartiallyRefunded
+	}
+	return ""
+}
+
+// parseAmount turns gateway amounts such as "150000.00" into whole rupiah.
+func parseAmount(value string) int64 {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(math.Round(amount))
+}
@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"Go-Starter-Template/domain"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMidtransServerKey = "SB-Mid-server-TEST"
+
+func loadPayload(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read payload %s: %v", name, err)
+	}
+	return body
+}
+
+func TestMidtransVerifyWebhook(t *testing.T) {
+	gateway := NewMidtransGateway(MidtransConfig{ServerKey: testMidtransServerKey})
+
+	tests := []struct {
+		name    string
+		payload string
+		status  string
+	}{
+		{name: "settlement", payload: "midtrans_settlement.json", status: domain.TransactionStatusPaid},
+		{name: "expire", payload: "midtrans_expire.json", status: domain.TransactionStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := gateway.VerifyWebhook(nil, loadPayload(t, tt.payload))
+			if err != nil {
+				t.Fatalf("VerifyWebhook() error = %v", err)
+			}
+			if status.Status != tt.status {
+				t.Errorf("Status = %q, want %q", status.Status, tt.status)
+			}
+			if status.OrderID != "QWER1234" || status.Amount != 150000 {
+				t.Errorf("got order %q amount %d", status.OrderID, status.Amount)
+			}
+		})
+	}
+}
+
+func TestMidtransVerifyWebhookRejectsTampering(t *testing.T) {
+	body := loadPayload(t, "midtrans_settlement.json")
+
+	tests := []struct {
+		name      string
+		serverKey string
+		body      []byte
+	}{
+		{name: "wrong server key", serverKey: "SB-Mid-server-OTHER", body: body},
+		{name: "tampered amount", serverKey: testMidtransServerKey, body: bytes.Replace(body, []byte(`"150000.00"`), []byte(`"1500.00"`), 1)},
+		{name: "not json", serverKey: testMidtransServerKey, body: []byte("order_id=QWER1234")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewMidtransGateway(MidtransConfig{ServerKey: tt.serverKey})
+			if _, err := gateway.VerifyWebhook(nil, tt.body); !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhook)
+			}
+		})
+	}
+}
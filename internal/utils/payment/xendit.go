@@ -0,0 +1,206 @@
+package payment
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/utils"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const xenditAPIURL = "https://api.xendit.co"
+
+type (
+	XenditConfig struct {
+		SecretKey     string
+		CallbackToken string
+		APIURL        string
+	}
+
+	xenditGateway struct {
+		config     XenditConfig
+		httpClient *http.Client
+	}
+
+	xenditInvoice struct {
+		ID         string  `json:"id"`
+		ExternalID string  `json:"external_id"`
+		Status     string  `json:"status"`
+		Amount     float64 `json:"amount"`
+		InvoiceURL string  `json:"invoice_url"`
+	}
+)
+
+func LoadXenditConfig() XenditConfig {
+	apiURL := utils.GetConfig("XENDIT_API_URL")
+	if apiURL == "" {
+		apiURL = xenditAPIURL
+	}
+
+	return XenditConfig{
+		SecretKey:     utils.GetConfig("XENDIT_SECRET_KEY"),
+		CallbackToken: utils.GetConfig("XENDIT_CALLBACK_TOKEN"),
+		APIURL:        strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+func NewXenditGateway(xenditConfig XenditConfig) PaymentGateway {
+	return &xenditGateway{
+		config:     xenditConfig,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (g *xenditGateway) Name() string {
+	return GatewayXendit
+}
+
+func (g *xenditGateway) CreateCheckout(ctx context.Context, req CheckoutRequest) (CheckoutResult, error) {
+	payload := map[string]any{
+		"external_id":      req.OrderID,
+		"amount":           req.Amount,
+		"description":      req.Description,
+		"payer_email":      req.CustomerEmail,
+		"currency":         "IDR",
+		"invoice_duration": max(int64(req.ExpiresIn/time.Second), 1), // seconds
+		"customer": map[string]any{
+			"given_names": req.CustomerName,
+			"email":       req.CustomerEmail,
+		},
+	}
+
+	var invoice xenditInvoice
+	if err := g.do(ctx, http.MethodPost, "/v2/invoices", payload, nil, &invoice); err != nil {
+		return CheckoutResult{}, err
+	}
+
+	return CheckoutResult{
+		Reference:   invoice.ID,
+		RedirectURL: invoice.InvoiceURL,
+	}, nil
+}
+
+// VerifyWebhook compares the x-callback-token header with the verification
+// token configured on the Xendit dashboard.
+func (g *xenditGateway) VerifyWebhook(header http.Header, body []byte) (PaymentStatus, error) {
+	token := header.Get("X-Callback-Token")
+	if g.config.CallbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.config.CallbackToken)) != 1 {
+		return PaymentStatus{}, ErrInvalidWebhook
+	}
+
+	var invoice xenditInvoice
+	if err := json.Unmarshal(body, &invoice); err != nil {
+		return PaymentStatus{}, ErrInvalidWebhook
+	}
+	return invoice.toPaymentStatus(), nil
+}
+
+func (g *xenditGateway) QueryStatus(ctx context.Context, _, reference string) (PaymentStatus, error) {
+	var invoice xenditInvoice
+	if err := g.do(ctx, http.MethodGet, "/v2/invoices/"+url.PathEscape(reference), nil, nil, &invoice); err != nil {
+		return PaymentStatus{}, err
+	}
+	return invoice.toPaymentStatus(), nil
+}
+
+func (g *xenditGateway) Refund(ctx context.Context, req RefundRequest) (RefundResult, error) {
+	payload := map[string]any{
+		"invoice_id": req.Reference,
+		"amount":     req.Amount,
+		"reason":     "OTHERS",
+		"metadata": map[string]any{
+			"reason": req.Reason,
+		},
+	}
+	header := http.Header{}
+	header.Set("Idempotency-key", req.RefundKey)
+
+	var resp struct {
+		ID     string  `json:"id"`
+		Amount float64 `json:"amount"`
+		Status string  `json:"status"`
+	}
+	if err := g.do(ctx, http.MethodPost, "/refunds", payload, header, &resp); err != nil {
+		return RefundResult{}, err
+	}
+	if resp.Status == "FAILED" {
+		return RefundResult{}, fmt.Errorf("xendit refund %s failed", resp.ID)
+	}
+
+	return RefundResult{
+		RefundID: resp.ID,
+		Amount:   int64(resp.Amount),
+	}, nil
+}
+
+func (g *xenditGateway) Cancel(ctx context.Context, _, reference string) error {
+	var invoice xenditInvoice
+	return g.do(ctx, http.MethodPost, "/invoices/"+url.PathEscape(reference)+"/expire!", nil, nil, &invoice)
+}
+
+func (g *xenditGateway) do(ctx context.Context, method, endpoint string, payload any, header http.Header, out any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.config.APIURL+endpoint, body)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.SetBasicAuth(g.config.SecretKey, "")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrGatewayPaymentNotFound
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("xendit API error: %s - %s", resp.Status, string(bodyBytes))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (i xenditInvoice) toPaymentStatus() PaymentStatus {
+	return PaymentStatus{
+		OrderID:   i.ExternalID,
+		Reference: i.ID,
+		Status:    xenditStatus(i.Status),
+		RawStatus: i.Status,
+		Amount:    int64(i.Amount),
+	}
+}
+
+func xenditStatus(status string) string {
+	switch status {
+	case "PAID", "SETTLED":
+		return domain.TransactionStatusPaid
+	case "EXPIRED":
+		return domain.TransactionStatusFailed
+	case "PENDING":
+		return domain.TransactionStatusPending
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package payment
+
+import (
+	"Go-Starter-Template/domain"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const testXenditCallbackToken = "xnd-callback-TEST"
+
+func callbackHeader(token string) http.Header {
+	header := http.Header{}
+	header.Set("X-Callback-Token", token)
+	return header
+}
+
+func TestXenditVerifyWebhook(t *testing.T) {
+	gateway := NewXenditGateway(XenditConfig{CallbackToken: testXenditCallbackToken})
+
+	tests := []struct {
+		name    string
+		payload string
+		status  string
+	}{
+		{name: "paid", payload: "xendit_invoice_paid.json", status: domain.TransactionStatusPaid},
+		{name: "expired", payload: "xendit_invoice_expired.json", status: domain.TransactionStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := gateway.VerifyWebhook(callbackHeader(testXenditCallbackToken), loadPayload(t, tt.payload))
+			if err != nil {
+				t.Fatalf("VerifyWebhook() error = %v", err)
+			}
+			if status.Status != tt.status {
+				t.Errorf("Status = %q, want %q", status.Status, tt.status)
+			}
+			if status.OrderID != "QWER1234" || status.Reference != "67f9c2a1e4b0d3a5c1f2e8b7" {
+				t.Errorf("got order %q reference %q", status.OrderID, status.Reference)
+			}
+		})
+	}
+}
+
+func TestXenditVerifyWebhookRejectsTampering(t *testing.T) {
+	body := loadPayload(t, "xendit_invoice_paid.json")
+
+	tests := []struct {
+		name          string
+		callbackToken string
+		header        http.Header
+	}{
+		{name: "wrong token", callbackToken: testXenditCallbackToken, header: callbackHeader("xnd-callback-OTHER")},
+		{name: "missing token", callbackToken: testXenditCallbackToken, header: http.Header{}},
+		{name: "token not configured", callbackToken: "", header: callbackHeader("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := NewXenditGateway(XenditConfig{CallbackToken: tt.callbackToken})
+			if _, err := gateway.VerifyWebhook(tt.header, body); !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhook)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package pdfpages
+
+import (
+	"bytes"
+	"errors"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"io"
+	"regexp"
+)
+
+var (
+	ErrInvalidPDF   = errors.New("invalid or encrypted PDF")
+	ErrTooManyPages = errors.New("PDF has too many pages")
+)
+
+// textOperator shows text in a content stream. Looking for BT is not enough,
+// generators open empty text objects just to set the font.
+var textOperator = regexp.MustCompile(`\bT[jJ]\b|[)>]\s*['"]`)
+
+func init() {
+	// pdfcpu writes a configuration directory to the home folder otherwise
+	model.ConfigPath = "disable"
+}
+
+// Page is one page of a PDF. A page that is nothing but one picture, a
+// scanned or photographed receipt, comes as Image, anything else, such as an
+// e-receipt with real text, as a PDF of its own in PDF.
+type Page struct {
+	Number int
+	Image  []byte
+	PDF    []byte
+}
+
+// Split breaks data into its pages, at most maxPages of them.
+func Split(data []byte, maxPages int) (pages []Page, err error) {
+	// pdfcpu panics on some malformed files instead of returning an error
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, ErrInvalidPDF
+		}
+	}()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(data), conf)
+	if err != nil || ctx.PageCount == 0 {
+		return nil, ErrInvalidPDF
+	}
+	if maxPages > 0 && ctx.PageCount > maxPages {
+		return nil, ErrTooManyPages
+	}
+
+	pages = make([]Page, 0, ctx.PageCount)
+	for number := 1; number <= ctx.PageCount; number++ {
+		page := Page{Number: number}
+		if page.Image, err = scannedImage(ctx, number); err != nil {
+			return nil, err
+		}
+		if page.Image == nil {
+			r, err := api.ExtractPage(ctx, number)
+			if err != nil {
+				return nil, err
+			}
+			if page.PDF, err = io.ReadAll(r); err != nil {
+				return nil, err
+			}
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// scannedImage returns the picture of a page without text that shows a
+// single JPEG or PNG, nil for any other page.
+func scannedImage(ctx *model.Context, number int) ([]byte, error) {
+	content, err := pdfcpu.ExtractPageContent(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if content != nil {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil, err
+		}
+		if textOperator.Match(data) {
+			return nil, nil
+		}
+	}
+
+	images, err := pdfcpu.ExtractPageImages(ctx, number, false)
+	if err != nil || len(images) != 1 {
+		return nil, err
+	}
+	for _, image := range images {
+		if image.FileType != "jpg" && image.FileType != "png" {
+			return nil, nil
+		}
+		return io.ReadAll(image)
+	}
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// farFuture stands in for "no expiry", fiber passes 0 for keys that never
+// expire.
+var farFuture = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Storage is a fiber.Storage kept in Postgres, so limiter counters are
+// shared between API instances instead of living in each process.
+type Storage struct {
+	db *gorm.DB
+}
+
+var _ fiber.Storage = (*Storage)(nil)
+
+func NewStorage(db *gorm.DB) *Storage {
+	return &Storage{db: db}
+}
+
+func (s *Storage) Get(key string) ([]byte, error) {
+	var entry entities.RateLimitEntry
+	err := s.db.WithContext(context.Background()).
+		First(&entry, "key = ? AND expires_at > ?", key, time.Now()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Value, nil
+}
+
+func (s *Storage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+
+	expiresAt := farFuture
+	if exp > 0 {
+		expiresAt = time.Now().Add(exp)
+	}
+	return s.db.WithContext(context.Background()).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at"}),
+		}).
+		Create(&entities.RateLimitEntry{Key: key, Value: val, ExpiresAt: expiresAt}).Error
+}
+
+func (s *Storage) Delete(key string) error {
+	return s.db.WithContext(context.Background()).
+		Where("key = ?", key).
+		Delete(&entities.RateLimitEntry{}).Error
+}
+
+func (s *Storage) Reset() error {
+	return s.db.WithContext(context.Background()).
+		Where("1 = 1").
+		Delete(&entities.RateLimitEntry{}).Error
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// PurgeExpired removes entries the limiter will never read again.
+func (s *Storage) PurgeExpired(ctx context.Context) error {
+	return s.db.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&entities.RateLimitEntry{}).Error
+}
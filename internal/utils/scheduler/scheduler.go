@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type Job func(ctx context.Context) error
+
+// Every runs job once per interval until ctx is cancelled. A non positive
+// interval disables the job.
+func Every(ctx context.Context, name string, interval time.Duration, job Job) {
+	if interval <= 0 {
+		log.Printf("[scheduler] %s disabled", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("[scheduler] %s failed: %v", name, err)
+			}
+		}
+	}
+}
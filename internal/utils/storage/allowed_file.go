@@ -1,6 +1,6 @@
 package storage
 
 var (
-	AllowImage    = []string{"image/jpg", "image/jpeg", "image/png"}
-	AllowImagePdf = []string{"image/jpg", "image/jpeg", "image/png", "application/pdf"}
+	AllowImage    = []string{"image/jpg", "image/jpeg", "image/png", "image/webp", "image/heic", "image/heif"}
+	AllowImagePdf = []string{"image/jpg", "image/jpeg", "image/png", "image/webp", "image/heic", "image/heif", "application/pdf"}
 )
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DriverS3     = "s3"
+	DriverMinIO  = "minio"
+	DriverLocal  = "local"
+	DriverMemory = "memory"
+)
+
+var (
+	ErrObjectNotFound  = errors.New("object not found")
+	ErrObjectTooLarge  = errors.New("object too large")
+	ErrInvalidMimetype = errors.New("invalid mimetype")
+	ErrNoFile          = errors.New("no file uploaded")
+)
+
+type (
+	// BlobStore keeps uploaded files. Objects are private and addressed by
+	// key, which is what rows store. Rows written before that hold the link
+	// from GetPublicLinkKey, GetObjectKeyFromLink maps both to the key and
+	// any other link to "".
+	BlobStore interface {
+		UploadFile(filename string, f *multipart.FileHeader, foldername string, mv ...string) (string, error)
+		UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error)
+		UploadBytes(objectKey string, data []byte, contentType string) (string, error)
+		GetFile(objectKey string) ([]byte, error)
+		// GetFileLimited is GetFile for objects a client wrote, it fails
+		// with ErrObjectTooLarge instead of reading more than limit bytes.
+		GetFileLimited(objectKey string, limit int64) ([]byte, error)
+		PresignGetURL(objectKey string, ttl time.Duration, downloadName string) (string, error)
+		// PresignPutURL signs an upload of exactly size bytes of contentType.
+		PresignPutURL(objectKey string, ttl time.Duration, contentType string, size int64) (string, error)
+		DeleteFile(objectKey string) error
+		GetPublicLinkKey(objectKey string) string
+		GetObjectKeyFromLink(link string) string
+		// SignedLink turns a stored key or link into one the client can
+		// open for a short while.
+		SignedLink(ref string) string
+	}
+
+	// FileServer is implemented by stores whose links point back at this
+	// API, the app mounts Serve for GET and Receive for PUT under Route.
+	FileServer interface {
+		Route() string
+		Serve(c *fiber.Ctx) error
+		Receive(c *fiber.Ctx) error
+	}
+
+	// File is an upload read into memory, ContentType is sniffed from Data.
+	File struct {
+		Data        []byte
+		ContentType string
+	}
+
+	StorageConfig struct {
+		Driver    string
+		Bucket    string
+		Region    string
+		AccessKey string
+		SecretKey string
+		// Endpoint points the S3 client at an S3 compatible server such as MinIO.
+		Endpoint  string
+		PathStyle bool
+		// PublicURL is the base of public links, derived from the driver when empty.
+		PublicURL  string
+		LocalDir   string
+		SigningKey string
+		// LinkTTL is how long links from SignedLink work.
+		LinkTTL time.Duration
+	}
+)
+
+func LoadStorageConfig() StorageConfig {
+	driver := strings.ToLower(utils.GetConfig("STORAGE_DRIVER"))
+	if driver == "" {
+		driver = DriverS3
+	}
+
+	return StorageConfig{
+		Driver:     driver,
+		Bucket:     utils.GetConfig("AWS_S3_BUCKET"),
+		Region:     utils.GetConfig("AWS_S3_REGION"),
+		AccessKey:  utils.GetConfig("AWS_ACCESS_KEY"),
+		SecretKey:  utils.GetConfig("AWS_SECRET_KEY"),
+		Endpoint:   strings.TrimSuffix(utils.GetConfig("STORAGE_ENDPOINT"), "/"),
+		PathStyle:  utils.GetBoolConfig("STORAGE_PATH_STYLE", driver == DriverMinIO),
+		PublicURL:  strings.TrimSuffix(utils.GetConfig("STORAGE_PUBLIC_URL"), "/"),
+		LocalDir:   utils.GetConfig("STORAGE_LOCAL_DIR"),
+		SigningKey: utils.GetConfig("STORAGE_SIGNING_KEY"),
+		LinkTTL:    utils.GetDurationConfig("STORAGE_LINK_TTL", 15*time.Minute),
+	}
+}
+
+// NewBlobStore returns the backend named by config.Driver.
+func NewBlobStore(config StorageConfig) (BlobStore, error) {
+	switch config.Driver {
+	case DriverS3:
+		return NewS3Store(config)
+	case DriverMinIO:
+		if config.Endpoint == "" {
+			return nil, errors.New("storage: STORAGE_ENDPOINT is required for the minio driver")
+		}
+		return NewS3Store(config)
+	case DriverLocal:
+		return NewLocalStore(config)
+	case DriverMemory:
+		return NewMemoryStore(config), nil
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", config.Driver)
+	}
+}
+
+// ReadUpload reads an uploaded file, its mimetype has to be one of mv when
+// mv is given.
+func ReadUpload(f *multipart.FileHeader, mv ...string) (File, error) {
+	if f == nil {
+		return File{}, ErrNoFile
+	}
+
+	file, err := f.Open()
+	if err != nil {
+		return File{}, err
+	}
+	defer func(file multipart.File) {
+		err := file.Close()
+		if err != nil {
+			return
+		}
+	}(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return File{}, err
+	}
+	return CheckFile(data, mv...)
+}
+
+// CheckFile sniffs the mimetype of data, which has to be one of mv when mv
+// is given. Clients pick the Content-Type they upload with, so it is never
+// taken from them.
+func CheckFile(data []byte, mv ...string) (File, error) {
+	mimetype := DetectContentType(data)
+	if len(mv) > 0 && !slices.Contains(mv, mimetype) {
+		return File{}, ErrInvalidMimetype
+	}
+	return File{Data: data, ContentType: mimetype}, nil
+}
+
+// DetectContentType is http.DetectContentType, which does not know HEIC
+// and HEIF, the format iPhones take photos in.
+func DetectContentType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		switch string(data[8:12]) {
+		case "heic", "heix", "hevc", "hevx", "heim", "heis":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// linkKey is GetObjectKeyFromLink for stores whose links are base + "/" + key.
+// A bare key, as stored by rows, is returned as is.
+func linkKey(base, link string) string {
+	if !strings.Contains(link, "://") {
+		return link
+	}
+	pref := base + "/"
+	if !strings.HasPrefix(link, pref) {
+		return ""
+	}
+	return strings.TrimPrefix(link, pref)
+}
+
+// signedLink presigns the object behind ref. Links to other hosts, such as
+// a provider avatar, are not ours to sign and come back unchanged.
+func signedLink(store BlobStore, ref string, ttl time.Duration) string {
+	if ref == "" {
+		return ""
+	}
+	objectKey := store.GetObjectKeyFromLink(ref)
+	if objectKey == "" {
+		return ref
+	}
+
+	link, err := store.PresignGetURL(objectKey, ttl, "")
+	if err != nil {
+		log.Printf("storage: presign %s: %v", objectKey, err)
+		return ""
+	}
+	return link
+}
+
+// readLimited reads r to the end unless it holds more than limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrObjectTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"Go-Starter-Template/internal/utils"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localRoute is where the API serves files of the local driver.
+const localRoute = "/storage"
+
+type localStore struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+	linkTTL    time.Duration
+}
+
+// NewLocalStore keeps objects as files under config.LocalDir and serves them
+// from this API, meant for development. Like the private bucket only
+// presigned links work, Serve and Receive check their signature.
+func NewLocalStore(config StorageConfig) (BlobStore, error) {
+	dir := config.LocalDir
+	if dir == "" {
+		dir = "./uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: create %s: %w", dir, err)
+	}
+
+	publicURL := config.PublicURL
+	if publicURL == "" {
+		publicURL = strings.TrimSuffix(utils.GetConfig("APP_URL"), "/") + localRoute
+	}
+
+	// without a configured key, links signed before a restart stop working
+	signingKey := []byte(config.SigningKey)
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &localStore{
+		dir:        dir,
+		publicURL:  publicURL,
+		signingKey: signingKey,
+		linkTTL:    config.LinkTTL,
+	}, nil
+}
+
+// path maps a key to a file, keys cannot climb out of the storage directory.
+func (l *localStore) path(objectKey string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(path.Clean("/"+objectKey)))
+}
+
+func (l *localStore) UploadFile(filename string, f *multipart.FileHeader, folderName string, mv ...string) (string, error) {
+	return l.UpdateFile(fmt.Sprintf("%s/%s", folderName, filename), f, mv...)
+}
+
+func (l *localStore) UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error) {
+	file, err := ReadUpload(f, mv...)
+	if err != nil {
+		return "", err
+	}
+	return l.UploadBytes(objectKey, file.Data, file.ContentType)
+}
+
+func (l *localStore) UploadBytes(objectKey string, data []byte, contentType string) (string, error) {
+	file := l.path(objectKey)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return "", err
+	}
+	return objectKey, nil
+}
+
+func (l *localStore) GetFile(objectKey string) ([]byte, error) {
+	data, err := os.ReadFile(l.path(objectKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
+
+func (l *localStore) GetFileLimited(objectKey string, limit int64) ([]byte, error) {
+	file, err := os.Open(l.path(objectKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readLimited(file, limit)
+}
+
+func (l *localStore) PresignGetURL(objectKey string, ttl time.Duration, downloadName string) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	if downloadName != "" {
+		query.Set("filename", downloadName)
+	}
+	query.Set("signature", l.sign(fiber.MethodGet, objectKey, expires, downloadName))
+	return l.GetPublicLinkKey(objectKey) + "?" + query.Encode(), nil
+}
+
+func (l *localStore) PresignPutURL(objectKey string, ttl time.Duration, contentType string, size int64) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	length := strconv.FormatInt(size, 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("size", length)
+	query.Set("signature", l.sign(fiber.MethodPut, objectKey, expires, contentType+"\n"+length))
+	return l.GetPublicLinkKey(objectKey) + "?" + query.Encode(), nil
+}
+
+func (l *localStore) DeleteFile(objectKey string) error {
+	err := os.Remove(l.path(objectKey))
+	if errors.Is(err, os.ErrNotExist) {
+		// same as S3, deleting a missing object is not an error
+		return nil
+	}
+	return err
+}
+
+func (l *localStore) GetPublicLinkKey(objectKey string) string {
+	return l.publicURL + "/" + objectKey
+}
+
+func (l *localStore) GetObjectKeyFromLink(link string) string {
+	return linkKey(l.publicURL, link)
+}
+
+func (l *localStore) SignedLink(ref string) string {
+	return signedLink(l, ref, l.linkTTL)
+}
+
+func (l *localStore) Route() string {
+	return localRoute
+}
+
+func (l *localStore) Serve(c *fiber.Ctx) error {
+	downloadName := c.Query("filename")
+	objectKey, err := l.verify(c, fiber.MethodGet, downloadName)
+	if err != nil {
+		return err
+	}
+
+	data, err := l.GetFile(objectKey)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(objectKey))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	if downloadName != "" {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", downloadName))
+	}
+	return c.Send(data)
+}
+
+func (l *localStore) Receive(c *fiber.Ctx) error {
+	contentType := c.Get(fiber.HeaderContentType)
+	length := c.Query("size")
+	objectKey, err := l.verify(c, fiber.MethodPut, contentType+"\n"+length)
+	if err != nil {
+		return err
+	}
+	if strconv.Itoa(len(c.Body())) != length {
+		return fiber.ErrRequestEntityTooLarge
+	}
+
+	if _, err := l.UploadBytes(objectKey, c.Body(), contentType); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// verify checks the signature of a presigned link and returns its key.
+func (l *localStore) verify(c *fiber.Ctx, method, extra string) (string, error) {
+	objectKey, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return "", fiber.ErrBadRequest
+	}
+
+	expires := c.Query("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return "", fiber.ErrForbidden
+	}
+	if !hmac.Equal([]byte(c.Query("signature")), []byte(l.sign(method, objectKey, expires, extra))) {
+		return "", fiber.ErrForbidden
+	}
+	return objectKey, nil
+}
+
+func (l *localStore) sign(method, objectKey, expires, extra string) string {
+	mac := hmac.New(sha256.New, l.signingKey)
+	mac.Write([]byte(method + "\n" + objectKey + "\n" + expires + "\n" + extra))
+	return hex.EncodeToString(mac.Sum(nil))
+}
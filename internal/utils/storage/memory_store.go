@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	memoryObject struct {
+		data        []byte
+		contentType string
+	}
+
+	memoryStore struct {
+		mu        sync.RWMutex
+		objects   map[string]memoryObject
+		publicURL string
+		linkTTL   time.Duration
+	}
+)
+
+// NewMemoryStore keeps objects in memory until the process exits, for tests
+// and for running the API without any storage at hand.
+func NewMemoryStore(config StorageConfig) BlobStore {
+	publicURL := config.PublicURL
+	if publicURL == "" {
+		publicURL = "memory://blobs"
+	}
+	return &memoryStore{
+		objects:   make(map[string]memoryObject),
+		publicURL: publicURL,
+		linkTTL:   config.LinkTTL,
+	}
+}
+
+func (m *memoryStore) UploadFile(filename string, f *multipart.FileHeader, folderName string, mv ...string) (string, error) {
+	return m.UpdateFile(fmt.Sprintf("%s/%s", folderName, filename), f, mv...)
+}
+
+func (m *memoryStore) UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error) {
+	file, err := ReadUpload(f, mv...)
+	if err != nil {
+		return "", err
+	}
+	return m.UploadBytes(objectKey, file.Data, file.ContentType)
+}
+
+func (m *memoryStore) UploadBytes(objectKey string, data []byte, contentType string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[objectKey] = memoryObject{
+		data:        append([]byte(nil), data...),
+		contentType: contentType,
+	}
+	return objectKey, nil
+}
+
+func (m *memoryStore) GetFile(objectKey string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[objectKey]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return append([]byte(nil), object.data...), nil
+}
+
+func (m *memoryStore) GetFileLimited(objectKey string, limit int64) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	object, ok := m.objects[objectKey]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	if int64(len(object.data)) > limit {
+		return nil, ErrObjectTooLarge
+	}
+	return append([]byte(nil), object.data...), nil
+}
+
+func (m *memoryStore) PresignGetURL(objectKey string, ttl time.Duration, downloadName string) (string, error) {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	if downloadName != "" {
+		query.Set("filename", downloadName)
+	}
+	return m.GetPublicLinkKey(objectKey) + "?" + query.Encode(), nil
+}
+
+func (m *memoryStore) PresignPutURL(objectKey string, ttl time.Duration, contentType string, size int64) (string, error) {
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))
+	query.Set("content_type", contentType)
+	query.Set("size", strconv.FormatInt(size, 10))
+	return m.GetPublicLinkKey(objectKey) + "?" + query.Encode(), nil
+}
+
+func (m *memoryStore) DeleteFile(objectKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, objectKey)
+	return nil
+}
+
+func (m *memoryStore) GetPublicLinkKey(objectKey string) string {
+	return m.publicURL + "/" + objectKey
+}
+
+func (m *memoryStore) GetObjectKeyFromLink(link string) string {
+	return linkKey(m.publicURL, link)
+}
+
+func (m *memoryStore) SignedLink(ref string) string {
+	return signedLink(m, ref, m.linkTTL)
+}
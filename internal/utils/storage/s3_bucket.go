@@ -1,148 +1,183 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"io"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"strings"
+	"time"
 )
 
-type (
-	AwsS3 interface {
-		UploadFile(filename string, f *multipart.FileHeader, foldername string, mv ...string) (string, error)
-		UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error)
-		DeleteFile(objectKey string) error
-		GetPublicLinkKey(objectKey string) string
-		GetObjectKeyFromLink(link string) string
+type s3Store struct {
+	client    *s3.Client
+	bucket    string
+	publicURL string
+	linkTTL   time.Duration
+}
+
+// NewS3Store talks to AWS S3, or to an S3 compatible server such as MinIO
+// when config.Endpoint is set. Without an access key the default AWS
+// credential chain is used.
+func NewS3Store(storageConfig StorageConfig) (BlobStore, error) {
+	if storageConfig.Bucket == "" {
+		return nil, errors.New("storage: AWS_S3_BUCKET is required for the s3 and minio drivers")
 	}
-	awss3 struct {
-		client *s3.Client
-		bucket string
-		region string
+
+	region := storageConfig.Region
+	if region == "" {
+		if storageConfig.Endpoint == "" {
+			return nil, errors.New("storage: AWS_S3_REGION is required for the s3 driver")
+		}
+		// MinIO ignores the region but the SDK needs one to sign requests
+		region = "us-east-1"
 	}
-)
 
-func NewAwsS3() AwsS3 {
-	bucket := os.Getenv("AWS_S3_BUCKET")
-	region := os.Getenv("AWS_S3_REGION")
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
-		config.WithRegion(region),
-		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
-			os.Getenv("AWS_ACCESS_KEY"),
-			os.Getenv("AWS_SECRET_KEY"),
+	opts := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if storageConfig.AccessKey != "" {
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			storageConfig.AccessKey,
+			storageConfig.SecretKey,
 			"",
-		)),
-	)
-
+		)))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
 	if err != nil {
-		panic(fmt.Sprintf("failed to load AWS configuration: %v", err))
+		return nil, fmt.Errorf("storage: load AWS configuration: %w", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if storageConfig.Endpoint != "" {
+			o.BaseEndpoint = aws.String(storageConfig.Endpoint)
+		}
+		o.UsePathStyle = storageConfig.PathStyle
+	})
 
-	return &awss3{
-		client: client,
-		bucket: bucket,
-		region: region,
+	publicURL := storageConfig.PublicURL
+	if publicURL == "" {
+		switch {
+		case storageConfig.Endpoint != "" && storageConfig.PathStyle:
+			publicURL = fmt.Sprintf("%s/%s", storageConfig.Endpoint, storageConfig.Bucket)
+		case storageConfig.Endpoint != "":
+			publicURL = storageConfig.Endpoint
+		default:
+			publicURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", storageConfig.Bucket, region)
+		}
 	}
+
+	return &s3Store{
+		client:    client,
+		bucket:    storageConfig.Bucket,
+		publicURL: publicURL,
+		linkTTL:   storageConfig.LinkTTL,
+	}, nil
 }
-func (a *awss3) UploadFile(filename string, f *multipart.FileHeader, folderName string, mv ...string) (string, error) {
-	file, err := f.Open()
-	if err != nil {
-		return "", err
-	}
-	defer func(file multipart.File) {
-		err := file.Close()
-		if err != nil {
-			return
-		}
-	}(file)
 
-	mimetype, err := GetMimetype(file)
+func (a *s3Store) UploadFile(filename string, f *multipart.FileHeader, folderName string, mv ...string) (string, error) {
+	return a.UpdateFile(fmt.Sprintf("%s/%s", folderName, filename), f, mv...)
+}
+
+func (a *s3Store) UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error) {
+	file, err := ReadUpload(f, mv...)
 	if err != nil {
 		return "", err
 	}
+	return a.UploadBytes(objectKey, file.Data, file.ContentType)
+}
 
-	if len(mv) > 0 {
-		flag := false
-		for _, m := range mv {
-			if mimetype == m {
-				flag = true
-				break
-			}
-		}
-
-		if !flag {
-			return "", fmt.Errorf("invalid mimetype")
-		}
-	}
-
-	objectKey := fmt.Sprintf("%s/%s", folderName, filename)
-
-	_, err = a.client.PutObject(context.TODO(), &s3.PutObjectInput{
+func (a *s3Store) UploadBytes(objectKey string, data []byte, contentType string) (string, error) {
+	_, err := a.client.PutObject(context.TODO(), &s3.PutObjectInput{
 		Bucket:      aws.String(a.bucket),
 		Key:         aws.String(objectKey),
-		Body:        file,
-		ContentType: aws.String(mimetype),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
 	})
-
 	if err != nil {
 		return "", err
 	}
 
 	return objectKey, nil
 }
-func (a *awss3) UpdateFile(objectKey string, f *multipart.FileHeader, mv ...string) (string, error) {
-	file, err := f.Open()
+
+func (a *s3Store) GetFile(objectKey string) ([]byte, error) {
+	out, err := a.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(objectKey),
+	})
 	if err != nil {
-		return "", err
-	}
-	defer func(file multipart.File) {
-		err := file.Close()
-		if err != nil {
-			return
+		var notFound *types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return nil, ErrObjectNotFound
 		}
-	}(file)
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return io.ReadAll(out.Body)
+}
 
-	mimetype, err := GetMimetype(file)
+func (a *s3Store) GetFileLimited(objectKey string, limit int64) ([]byte, error) {
+	out, err := a.client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(objectKey),
+	})
 	if err != nil {
-		return "", err
+		var notFound *types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
 	}
+	defer out.Body.Close()
 
-	if len(mv) > 0 {
-		flag := false
-		for _, m := range mv {
-			if mimetype == m {
-				flag = true
-				break
-			}
-		}
+	if out.ContentLength != nil && *out.ContentLength > limit {
+		return nil, ErrObjectTooLarge
+	}
+	return readLimited(out.Body, limit)
+}
 
-		if !flag {
-			return "", fmt.Errorf("invalid mimetype")
-		}
+// PresignGetURL returns a link that downloads a private object until ttl
+// passes, S3 caps ttl at seven days.
+func (a *s3Store) PresignGetURL(objectKey string, ttl time.Duration, downloadName string) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(objectKey),
+	}
+	if downloadName != "" {
+		input.ResponseContentDisposition = aws.String(fmt.Sprintf("attachment; filename=%q", downloadName))
 	}
 
-	_, err = a.client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket:      aws.String(a.bucket),
-		Key:         aws.String(objectKey),
-		Body:        file,
-		ContentType: aws.String(mimetype),
-	})
+	req, err := s3.NewPresignClient(a.client).PresignGetObject(context.TODO(), input, s3.WithPresignExpires(ttl))
 	if err != nil {
 		return "", err
 	}
+	return req.URL, nil
+}
 
-	return objectKey, nil
+// PresignPutURL lets a client upload straight to the bucket. The request
+// has to carry contentType as its Content-Type header, and size is signed
+// as its Content-Length so nothing larger is accepted.
+func (a *s3Store) PresignPutURL(objectKey string, ttl time.Duration, contentType string, size int64) (string, error) {
+	req, err := s3.NewPresignClient(a.client).PresignPutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:        aws.String(a.bucket),
+		Key:           aws.String(objectKey),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
 }
-func (a *awss3) DeleteFile(objectKey string) error {
+
+func (a *s3Store) DeleteFile(objectKey string) error {
 	_, err := a.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
 		Bucket: aws.String(a.bucket),
 		Key:    aws.String(objectKey),
@@ -152,19 +187,17 @@ func (a *awss3) DeleteFile(objectKey string) error {
 	}
 	return nil
 }
-func (a *awss3) GetPublicLinkKey(objectKey string) string {
-	publicURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", a.bucket, a.region, objectKey)
-	return publicURL
+
+func (a *s3Store) GetPublicLinkKey(objectKey string) string {
+	return a.publicURL + "/" + objectKey
 }
-func (a *awss3) GetObjectKeyFromLink(link string) string {
-	pref := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", a.bucket, a.region)
 
-	if !strings.HasPrefix(link, pref) {
-		return ""
-	}
+func (a *s3Store) GetObjectKeyFromLink(link string) string {
+	return linkKey(a.publicURL, link)
+}
 
-	objectKey := strings.TrimPrefix(link, pref)
-	return objectKey
+func (a *s3Store) SignedLink(ref string) string {
+	return signedLink(a, ref, a.linkTTL)
 }
 
 func GetMimetype(f multipart.File) (string, error) {
@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/utility"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type (
+	AdminRepository interface {
+		SearchUsers(ctx context.Context, meta *pagination.Meta) ([]entities.User, error)
+		GetUserByID(ctx context.Context, id string) (*entities.User, error)
+		SetSuspended(ctx context.Context, id string, suspendedAt *time.Time, reason string) error
+		SetVerified(ctx context.Context, id string) error
+		SetSubscription(ctx context.Context, id string, subscribe bool) error
+		GetTransactions(ctx context.Context, meta *pagination.Meta) ([]entities.Transaction, error)
+		GetPaymentEvents(ctx context.Context, transactionID string) ([]entities.PaymentEvent, error)
+		CountUsers(ctx context.Context) (int64, error)
+		CountActiveUsers(ctx context.Context) (int64, error)
+		CountFoodItemsByStatus(ctx context.Context) (map[string]int64, error)
+		GetTopWastedItems(ctx context.Context, statuses []string, limit int) ([]WastedItem, error)
+	}
+
+	WastedItem struct {
+		Name  string
+		Count int64
+	}
+
+	adminRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+// userFilters is an explicit list so that secrets on the user row can never
+// be filtered or sorted on.
+var userFilters = []utility.Option{
+	utility.AddCustomField("id", "users.id = ?", "users.id"),
+	utility.AddCustomField("search", "(users.name ILIKE ? OR users.username ILIKE ? OR users.email ILIKE ?)", "users.name"),
+	utility.AddCustomField("name", "users.name ILIKE ?", "users.name"),
+	utility.AddCustomField("username", "users.username ILIKE ?", "users.username"),
+	utility.AddCustomField("email", "users.email ILIKE ?", "users.email"),
+	utility.AddCustomField("role", "users.role = ?", "users.role"),
+	utility.AddCustomField("verified", "users.verified = ?", "users.verified"),
+	utility.AddCustomField("subscribe", "users.subscribe = ?", "users.subscribe"),
+	utility.AddCustomField("suspended", "(users.suspended_at IS NOT NULL) = ?", "users.suspended_at"),
+	utility.AddCustomField("created_at", "users.created_at = ?", "users.created_at"),
+}
+
+func (r *adminRepository) SearchUsers(ctx context.Context, meta *pagination.Meta) ([]entities.User, error) {
+	var users []entities.User
+
+	query := r.db.WithContext(ctx).Model(&entities.User{})
+	query = utility.WithFilters(query, meta, userFilters...)
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *adminRepository) GetUserByID(ctx context.Context, id string) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *adminRepository) SetSuspended(ctx context.Context, id string, suspendedAt *time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"suspended_at":     suspendedAt,
+			"suspended_reason": reason,
+		}).Error
+}
+
+func (r *adminRepository) SetVerified(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ?", id).
+		Update("verified", true).Error
+}
+
+func (r *adminRepository) SetSubscription(ctx context.Context, id string, subscribe bool) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ?", id).
+		Update("subscribe", subscribe).Error
+}
+
+func (r *adminRepository) GetTransactions(ctx context.Context, meta *pagination.Meta) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+
+	query := r.db.WithContext(ctx).Model(&entities.Transaction{})
+	query = utility.WithFilters(query, meta, utility.AddModels(entities.Transaction{}, "transactions"))
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *adminRepository) GetPaymentEvents(ctx context.Context, transactionID string) ([]entities.PaymentEvent, error) {
+	var events []entities.PaymentEvent
+	if err := r.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *adminRepository) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&entities.User{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *adminRepository) CountActiveUsers(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&entities.FoodItem{}).
+		Distinct("user_id").
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *adminRepository) CountFoodItemsByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&entities.FoodItem{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *adminRepository) GetTopWastedItems(ctx context.Context, statuses []string, limit int) ([]WastedItem, error) {
+	var items []WastedItem
+	if err := r.db.WithContext(ctx).Model(&entities.FoodItem{}).
+		Select("LOWER(name) AS name, COUNT(*) AS count").
+		Where("status IN ?", statuses).
+		Group("LOWER(name)").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,304 @@
+package admin
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/session"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// wastedStatuses are the food item statuses counted as waste.
+var wastedStatuses = []string{"Expired", "Damaged"}
+
+const topWastedItemsLimit = 10
+
+type (
+	AdminService interface {
+		SearchUsers(ctx context.Context, meta pagination.Meta) (domain.AdminUserListResponse, error)
+		GetUser(ctx context.Context, userID string) (domain.AdminUserResponse, error)
+		SuspendUser(ctx context.Context, adminID string, userID string, req domain.SuspendUserRequest) (domain.AdminUserResponse, error)
+		UnsuspendUser(ctx context.Context, userID string) (domain.AdminUserResponse, error)
+		VerifyUser(ctx context.Context, userID string) (domain.AdminUserResponse, error)
+		UpdateSubscription(ctx context.Context, userID string, req domain.UpdateSubscriptionRequest) (domain.AdminUserResponse, error)
+		GetTransactions(ctx context.Context, meta pagination.Meta) (domain.AdminTransactionListResponse, error)
+		GetPaymentEvents(ctx context.Context, transactionID string) ([]domain.PaymentEventResponse, error)
+		GetWasteStatistics(ctx context.Context) (domain.WasteStatistics, error)
+	}
+
+	adminService struct {
+		adminRepository AdminRepository
+		sessionService  session.SessionService
+		auditService    audit.AuditService
+	}
+)
+
+func NewAdminService(adminRepository AdminRepository, sessionService session.SessionService, auditService audit.AuditService) AdminService {
+	return &adminService{
+		adminRepository: adminRepository,
+		sessionService:  sessionService,
+		auditService:    auditService,
+	}
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, meta pagination.Meta) (domain.AdminUserListResponse, error) {
+	users, err := s.adminRepository.SearchUsers(ctx, &meta)
+	if err != nil {
+		return domain.AdminUserListResponse{}, err
+	}
+
+	items := make([]domain.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		items = append(items, toAdminUserResponse(user))
+	}
+
+	return domain.AdminUserListResponse{
+		Items: items,
+		Meta:  meta,
+	}, nil
+}
+
+func (s *adminService) GetUser(ctx context.Context, userID string) (domain.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+	return toAdminUserResponse(*user), nil
+}
+
+// SuspendUser blocks new logins and revokes every session, so the user is
+// signed out as soon as their access token is next checked.
+func (s *adminService) SuspendUser(ctx context.Context, adminID string, userID string, req domain.SuspendUserRequest) (domain.AdminUserResponse, error) {
+	if adminID == userID {
+		return domain.AdminUserResponse{}, domain.ErrCannotSuspendSelf
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+	if user.SuspendedAt != nil {
+		return domain.AdminUserResponse{}, domain.ErrUserAlreadySuspended
+	}
+
+	now := time.Now()
+	reason := strings.TrimSpace(req.Reason)
+	if err := s.adminRepository.SetSuspended(ctx, userID, &now, reason); err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+	if err := s.sessionService.RevokeAllSessions(ctx, userID, ""); err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+
+	before := toAdminUserResponse(*user)
+	user.SuspendedAt = &now
+	user.SuspendedReason = reason
+	return s.recordUserChange(ctx, domain.AuditUserSuspended, before, *user), nil
+}
+
+func (s *adminService) UnsuspendUser(ctx context.Context, userID string) (domain.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+	if user.SuspendedAt == nil {
+		return domain.AdminUserResponse{}, domain.ErrUserNotSuspended
+	}
+
+	if err := s.adminRepository.SetSuspended(ctx, userID, nil, ""); err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+
+	before := toAdminUserResponse(*user)
+	user.SuspendedAt = nil
+	user.SuspendedReason = ""
+	return s.recordUserChange(ctx, domain.AuditUserUnsuspended, before, *user), nil
+}
+
+func (s *adminService) VerifyUser(ctx context.Context, userID string) (domain.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+	if user.Verified {
+		return domain.AdminUserResponse{}, domain.ErrAccountAlreadyVerified
+	}
+
+	if err := s.adminRepository.SetVerified(ctx, userID); err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+
+	before := toAdminUserResponse(*user)
+	user.Verified = true
+	return s.recordUserChange(ctx, domain.AuditUserVerified, before, *user), nil
+}
+
+func (s *adminService) UpdateSubscription(ctx context.Context, userID string, req domain.UpdateSubscriptionRequest) (domain.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+
+	if err := s.adminRepository.SetSubscription(ctx, userID, *req.Subscribe); err != nil {
+		return domain.AdminUserResponse{}, err
+	}
+
+	before := toAdminUserResponse(*user)
+	user.Subscribe = *req.Subscribe
+	return s.recordUserChange(ctx, domain.AuditUserSubscriptionUpdated, before, *user), nil
+}
+
+func (s *adminService) GetTransactions(ctx context.Context, meta pagination.Meta) (domain.AdminTransactionListResponse, error) {
+	transactions, err := s.adminRepository.GetTransactions(ctx, &meta)
+	if err != nil {
+		return domain.AdminTransactionListResponse{}, err
+	}
+
+	items := make([]domain.AdminTransactionResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		// transactions of deleted accounts are kept without an owner
+		userID := ""
+		if transaction.UserID != nil {
+			userID = transaction.UserID.String()
+		}
+		items = append(items, domain.AdminTransactionResponse{
+			ID:               transaction.ID.String(),
+			UserID:           userID,
+			OrderID:          transaction.OrderID,
+			Status:           transaction.Status,
+			Amount:           transaction.Amount,
+			RefundedAmount:   transaction.RefundedAmount,
+			Gateway:          transaction.Gateway,
+			GatewayReference: transaction.GatewayReference,
+			PaidAt:           transaction.PaidAt,
+			CreatedAt:        transaction.CreatedAt,
+		})
+	}
+
+	return domain.AdminTransactionListResponse{
+		Items: items,
+		Meta:  meta,
+	}, nil
+}
+
+func (s *adminService) GetPaymentEvents(ctx context.Context, transactionID string) ([]domain.PaymentEventResponse, error) {
+	events, err := s.adminRepository.GetPaymentEvents(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.PaymentEventResponse, 0, len(events))
+	for _, event := range events {
+		var actorID string
+		if event.ActorID != nil {
+			actorID = event.ActorID.String()
+		}
+		res = append(res, domain.PaymentEventResponse{
+			ID:         event.ID.String(),
+			ActorID:    actorID,
+			Source:     event.Source,
+			Type:       event.Type,
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			Amount:     event.Amount,
+			Reference:  event.Reference,
+			Note:       event.Note,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+func (s *adminService) GetWasteStatistics(ctx context.Context) (domain.WasteStatistics, error) {
+	totalUsers, err := s.adminRepository.CountUsers(ctx)
+	if err != nil {
+		return domain.WasteStatistics{}, err
+	}
+	activeUsers, err := s.adminRepository.CountActiveUsers(ctx)
+	if err != nil {
+		return domain.WasteStatistics{}, err
+	}
+	byStatus, err := s.adminRepository.CountFoodItemsByStatus(ctx)
+	if err != nil {
+		return domain.WasteStatistics{}, err
+	}
+	topWasted, err := s.adminRepository.GetTopWastedItems(ctx, wastedStatuses, topWastedItemsLimit)
+	if err != nil {
+		return domain.WasteStatistics{}, err
+	}
+
+	var totalItems, wastedItems int64
+	for _, count := range byStatus {
+		totalItems += count
+	}
+	for _, status := range wastedStatuses {
+		wastedItems += byStatus[status]
+	}
+
+	var wasteRate float64
+	if totalItems > 0 {
+		wasteRate = float64(wastedItems) / float64(totalItems)
+	}
+
+	top := make([]domain.WastedItemCount, 0, len(topWasted))
+	for _, item := range topWasted {
+		top = append(top, domain.WastedItemCount{Name: item.Name, Count: item.Count})
+	}
+
+	return domain.WasteStatistics{
+		TotalUsers:     totalUsers,
+		ActiveUsers:    activeUsers,
+		TotalItems:     totalItems,
+		ItemsByStatus:  byStatus,
+		WastedItems:    wastedItems,
+		WasteRate:      wasteRate,
+		TopWastedItems: top,
+	}, nil
+}
+
+// recordUserChange audits an admin change to a user and returns the updated
+// user as a response.
+func (s *adminService) recordUserChange(ctx context.Context, action string, before domain.AdminUserResponse, user entities.User) domain.AdminUserResponse {
+	after := toAdminUserResponse(user)
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     action,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   after.ID,
+		Before:     before,
+		After:      after,
+	})
+	return after
+}
+
+func (s *adminService) getUser(ctx context.Context, userID string) (*entities.User, error) {
+	user, err := s.adminRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func toAdminUserResponse(user entities.User) domain.AdminUserResponse {
+	return domain.AdminUserResponse{
+		ID:              user.ID.String(),
+		Name:            user.Name,
+		Username:        user.Username,
+		Email:           user.Email,
+		Contact:         user.Contact,
+		Role:            user.Role,
+		Verified:        user.Verified,
+		Subscription:    user.Subscribe,
+		MFAEnabled:      user.MFAEnabled,
+		SuspendedAt:     user.SuspendedAt,
+		SuspendedReason: user.SuspendedReason,
+		CreatedAt:       user.CreatedAt,
+	}
+}
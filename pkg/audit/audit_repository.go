@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/utility"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// appendLockKey serialises appends across instances so every row links to
+// the one written right before it.
+const appendLockKey = 7_302_114_001
+
+type (
+	AuditRepository interface {
+		Append(ctx context.Context, log *entities.AuditLog, seal func(log *entities.AuditLog, prevHash string)) error
+		GetLogs(ctx context.Context, meta *pagination.Meta) ([]entities.AuditLog, error)
+		GetLogsAfter(ctx context.Context, afterID int64, limit int) ([]entities.AuditLog, error)
+	}
+
+	auditRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Append takes the chain lock, hands the hash of the current last row to
+// seal and inserts the sealed log.
+func (r *auditRepository) Append(ctx context.Context, log *entities.AuditLog, seal func(log *entities.AuditLog, prevHash string)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", appendLockKey).Error; err != nil {
+			return err
+		}
+
+		var last entities.AuditLog
+		prevHash := ""
+		err := tx.Select("hash").Order("id DESC").Take(&last).Error
+		switch {
+		case err == nil:
+			prevHash = last.Hash
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		seal(log, prevHash)
+		return tx.Create(log).Error
+	})
+}
+
+var logFilters = []utility.Option{
+	utility.AddCustomField("id", "audit_logs.id = ?", "audit_logs.id"),
+	utility.AddCustomField("actor_id", "audit_logs.actor_id = ?", "audit_logs.actor_id"),
+	utility.AddCustomField("action", "audit_logs.action ILIKE ?", "audit_logs.action"),
+	utility.AddCustomField("target_type", "audit_logs.target_type = ?", "audit_logs.target_type"),
+	utility.AddCustomField("target_id", "audit_logs.target_id = ?", "audit_logs.target_id"),
+	utility.AddCustomField("request_id", "audit_logs.request_id = ?", "audit_logs.request_id"),
+	utility.AddCustomField("ip_address", "audit_logs.ip_address = ?", "audit_logs.ip_address"),
+	utility.AddCustomField("created_from", "audit_logs.created_at >= ?", "audit_logs.created_at"),
+	utility.AddCustomField("created_to", "audit_logs.created_at <= ?", "audit_logs.created_at"),
+}
+
+func (r *auditRepository) GetLogs(ctx context.Context, meta *pagination.Meta) ([]entities.AuditLog, error) {
+	var logs []entities.AuditLog
+
+	query := r.db.WithContext(ctx).Model(&entities.AuditLog{})
+	query = utility.WithFilters(query, meta, logFilters...)
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (r *auditRepository) GetLogsAfter(ctx context.Context, afterID int64, limit int) ([]entities.AuditLog, error) {
+	var logs []entities.AuditLog
+	if err := r.db.WithContext(ctx).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,295 @@
+package audit
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/pagination"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/google/uuid"
+	"log"
+	"reflect"
+	"time"
+)
+
+// Locals set by the middlewares, fiber keeps them on the request context so
+// they can be read from the ctx handed to services.
+const (
+	userIDKey    = "user_id"
+	roleKey      = "role"
+	ipAddressKey = "ip_address"
+	userAgentKey = "user_agent"
+	requestIDKey = "requestid"
+)
+
+// redactedFields are never written to the log, only the fact they changed.
+var redactedFields = map[string]bool{
+	"password":           true,
+	"mfa_secret":         true,
+	"refresh_token_hash": true,
+	"token":              true,
+}
+
+// personalFields of a user are redacted as well: the log outlives an erased
+// account and cannot be edited, so it only ever names the user by ID.
+var personalFields = map[string]bool{
+	"name":            true,
+	"username":        true,
+	"email":           true,
+	"contact":         true,
+	"profile_picture": true,
+}
+
+type (
+	AuditService interface {
+		Record(ctx context.Context, entry domain.AuditEntry)
+		GetLogs(ctx context.Context, meta pagination.Meta) (domain.AuditLogListResponse, error)
+		Verify(ctx context.Context, batchSize int, anchor string) (domain.AuditVerifyResult, error)
+	}
+
+	auditService struct {
+		auditRepository AuditRepository
+	}
+
+	// chainFields is the hashed content of a row, json keeps the order fixed.
+	chainFields struct {
+		PrevHash   string `json:"prev_hash"`
+		ActorID    string `json:"actor_id"`
+		ActorRole  string `json:"actor_role"`
+		Action     string `json:"action"`
+		TargetType string `json:"target_type"`
+		TargetID   string `json:"target_id"`
+		Before     string `json:"before"`
+		After      string `json:"after"`
+		IPAddress  string `json:"ip_address"`
+		UserAgent  string `json:"user_agent"`
+		RequestID  string `json:"request_id"`
+		CreatedAt  string `json:"created_at"`
+	}
+)
+
+func NewAuditService(auditRepository AuditRepository) AuditService {
+	return &auditService{
+		auditRepository: auditRepository,
+	}
+}
+
+// Record never fails the caller, the audited change has already happened.
+func (s *auditService) Record(ctx context.Context, entry domain.AuditEntry) {
+	before, after, err := diff(entry.Before, entry.After, entry.TargetType == domain.AuditTargetUser)
+	if err != nil {
+		log.Printf("audit %s on %s %s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+
+	auditLog := entities.AuditLog{
+		ActorID:    parseActor(contextString(ctx, userIDKey)),
+		ActorRole:  contextString(ctx, roleKey),
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     before,
+		After:      after,
+		IPAddress:  contextString(ctx, ipAddressKey),
+		UserAgent:  contextString(ctx, userAgentKey),
+		RequestID:  contextString(ctx, requestIDKey),
+		// postgres keeps microseconds, the hash must match what is read back
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	if err := s.auditRepository.Append(ctx, &auditLog, seal); err != nil {
+		log.Printf("audit %s on %s %s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+func (s *auditService) GetLogs(ctx context.Context, meta pagination.Meta) (domain.AuditLogListResponse, error) {
+	logs, err := s.auditRepository.GetLogs(ctx, &meta)
+	if err != nil {
+		return domain.AuditLogListResponse{}, err
+	}
+
+	items := make([]domain.AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		var actorID string
+		if l.ActorID != nil {
+			actorID = l.ActorID.String()
+		}
+		items = append(items, domain.AuditLogResponse{
+			ID:         l.ID,
+			ActorID:    actorID,
+			ActorRole:  l.ActorRole,
+			Action:     l.Action,
+			TargetType: l.TargetType,
+			TargetID:   l.TargetID,
+			Before:     rawJSON(l.Before),
+			After:      rawJSON(l.After),
+			IPAddress:  l.IPAddress,
+			UserAgent:  l.UserAgent,
+			RequestID:  l.RequestID,
+			CreatedAt:  l.CreatedAt,
+			Hash:       l.Hash,
+		})
+	}
+
+	return domain.AuditLogListResponse{
+		Items: items,
+		Meta:  meta,
+	}, nil
+}
+
+// Verify walks the whole chain in id order and stops at the first row whose
+// link or hash does not match. anchor is an optional hash recorded earlier,
+// the chain must still contain it.
+func (s *auditService) Verify(ctx context.Context, batchSize int, anchor string) (domain.AuditVerifyResult, error) {
+	result := domain.AuditVerifyResult{Valid: true}
+
+	var afterID int64
+	for {
+		logs, err := s.auditRepository.GetLogsAfter(ctx, afterID, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(logs) == 0 {
+			return result, nil
+		}
+
+		for _, l := range logs {
+			if l.PrevHash != result.HeadHash {
+				result.Valid = false
+				result.BrokenAt = l.ID
+				result.Reason = "previous hash does not match, a row before it was changed or removed"
+				return result, nil
+			}
+			if computeHash(l) != l.Hash {
+				result.Valid = false
+				result.BrokenAt = l.ID
+				result.Reason = "hash does not match the row content"
+				return result, nil
+			}
+
+			if anchor != "" && l.Hash == anchor {
+				result.AnchorFound = true
+			}
+			result.Checked++
+			result.HeadID = l.ID
+			result.HeadHash = l.Hash
+		}
+		afterID = logs[len(logs)-1].ID
+	}
+}
+
+func seal(auditLog *entities.AuditLog, prevHash string) {
+	auditLog.PrevHash = prevHash
+	auditLog.Hash = computeHash(*auditLog)
+}
+
+func computeHash(auditLog entities.AuditLog) string {
+	var actorID string
+	if auditLog.ActorID != nil {
+		actorID = auditLog.ActorID.String()
+	}
+
+	content, _ := json.Marshal(chainFields{
+		PrevHash:   auditLog.PrevHash,
+		ActorID:    actorID,
+		ActorRole:  auditLog.ActorRole,
+		Action:     auditLog.Action,
+		TargetType: auditLog.TargetType,
+		TargetID:   auditLog.TargetID,
+		Before:     auditLog.Before,
+		After:      auditLog.After,
+		IPAddress:  auditLog.IPAddress,
+		UserAgent:  auditLog.UserAgent,
+		RequestID:  auditLog.RequestID,
+		CreatedAt:  auditLog.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// diff reduces two snapshots to the fields that differ. A missing snapshot
+// means the target was created or deleted and the other one is kept whole.
+func diff(before, after any, personal bool) (string, string, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return "", "", err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return "", "", err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalFields(beforeFields, personal)
+	if err != nil {
+		return "", "", err
+	}
+	afterJSON, err := marshalFields(afterFields, personal)
+	if err != nil {
+		return "", "", err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func toFields(snapshot any) (map[string]any, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]any)
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// marshalFields runs after the diff so a changed secret still shows up, just
+// without its value. personal redacts the personalFields of a user too.
+func marshalFields(fields map[string]any, personal bool) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+	for key := range fields {
+		if redactedFields[key] || (personal && personalFields[key]) {
+			fields[key] = "[redacted]"
+		}
+	}
+	content, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func contextString(ctx context.Context, key string) string {
+	value, _ := ctx.Value(key).(string)
+	return value
+}
+
+func parseActor(id string) *uuid.UUID {
+	actorID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &actorID
+}
+
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
@@ -0,0 +1,250 @@
+package dataexport
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type (
+	DataExportRepository interface {
+		CreateExport(ctx context.Context, export *entities.DataExport) error
+		GetExportByID(ctx context.Context, id string) (*entities.DataExport, error)
+		GetExportsByUser(ctx context.Context, userID string) ([]entities.DataExport, error)
+		HasActiveExport(ctx context.Context, userID string) (bool, error)
+		ClaimNextExport(ctx context.Context, staleBefore time.Time) (*entities.DataExport, error)
+		MarkReady(ctx context.Context, id string, objectKey string, size int64, expiresAt time.Time) error
+		MarkFailed(ctx context.Context, id string, reason string) error
+		GetExpiredExports(ctx context.Context, now time.Time) ([]entities.DataExport, error)
+		MarkExpired(ctx context.Context, id string) error
+
+		GetUser(ctx context.Context, userID string) (*entities.User, error)
+		GetFoodItems(ctx context.Context, userID string) ([]entities.FoodItem, error)
+		GetReceiptScans(ctx context.Context, userID string) ([]entities.ReceiptScan, error)
+		GetReceiptCorrections(ctx context.Context, userID string) ([]entities.ReceiptCorrection, error)
+		GetTransactions(ctx context.Context, userID string) ([]entities.Transaction, error)
+		GetPaymentEvents(ctx context.Context, userID string) ([]entities.PaymentEvent, error)
+		GetSessions(ctx context.Context, userID string) ([]entities.Session, error)
+		GetIdentities(ctx context.Context, userID string) ([]entities.UserIdentity, error)
+		GetActivity(ctx context.Context, userID string) ([]entities.AuditLog, error)
+	}
+
+	dataExportRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) CreateExport(ctx context.Context, export *entities.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *dataExportRepository) GetExportByID(ctx context.Context, id string) (*entities.DataExport, error) {
+	var export entities.DataExport
+	if err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) GetExportsByUser(ctx context.Context, userID string) ([]entities.DataExport, error) {
+	var exports []entities.DataExport
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *dataExportRepository) HasActiveExport(ctx context.Context, userID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&entities.DataExport{}).
+		Where("user_id = ? AND status IN ?", userID, []string{domain.DataExportStatusPending, domain.DataExportStatusProcessing}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ClaimNextExport moves the oldest pending export, or one whose worker died
+// before staleBefore, to processing. SKIP LOCKED lets several instances work
+// the queue without taking the same export.
+func (r *dataExportRepository) ClaimNextExport(ctx context.Context, staleBefore time.Time) (*entities.DataExport, error) {
+	next := r.db.Model(&entities.DataExport{}).
+		Select("id").
+		Where("status = ? OR (status = ? AND started_at < ?)", domain.DataExportStatusPending, domain.DataExportStatusProcessing, staleBefore).
+		Order("created_at ASC").
+		Limit(1).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+
+	var exports []entities.DataExport
+	if err := r.db.WithContext(ctx).
+		Model(&exports).
+		Clauses(clause.Returning{}).
+		Where("id = (?)", next).
+		Updates(map[string]interface{}{
+			"status":     domain.DataExportStatusProcessing,
+			"started_at": time.Now(),
+		}).Error; err != nil {
+		return nil, err
+	}
+	if len(exports) == 0 {
+		return nil, nil
+	}
+	return &exports[0], nil
+}
+
+func (r *dataExportRepository) MarkReady(ctx context.Context, id string, objectKey string, size int64, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.DataExport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       domain.DataExportStatusReady,
+			"object_key":   objectKey,
+			"size_bytes":   size,
+			"completed_at": time.Now(),
+			"expires_at":   expiresAt,
+			"error":        "",
+		}).Error
+}
+
+func (r *dataExportRepository) MarkFailed(ctx context.Context, id string, reason string) error {
+	return r.db.WithContext(ctx).Model(&entities.DataExport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       domain.DataExportStatusFailed,
+			"error":        reason,
+			"completed_at": time.Now(),
+		}).Error
+}
+
+func (r *dataExportRepository) GetExpiredExports(ctx context.Context, now time.Time) ([]entities.DataExport, error) {
+	var exports []entities.DataExport
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", domain.DataExportStatusReady, now).
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *dataExportRepository) MarkExpired(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&entities.DataExport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     domain.DataExportStatusExpired,
+			"object_key": "",
+		}).Error
+}
+
+func (r *dataExportRepository) GetUser(ctx context.Context, userID string) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.WithContext(ctx).Preload("Roles").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// The queries below are Unscoped, soft deleted rows are still data we hold.
+
+func (r *dataExportRepository) GetFoodItems(ctx context.Context, userID string) ([]entities.FoodItem, error) {
+	var items []entities.FoodItem
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *dataExportRepository) GetReceiptScans(ctx context.Context, userID string) ([]entities.ReceiptScan, error) {
+	var scans []entities.ReceiptScan
+	if err := r.db.WithContext(ctx).Unscoped().
+		Preload("Pages", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Order("number ASC") }).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&scans).Error; err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+func (r *dataExportRepository) GetReceiptCorrections(ctx context.Context, userID string) ([]entities.ReceiptCorrection, error) {
+	var corrections []entities.ReceiptCorrection
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&corrections).Error; err != nil {
+		return nil, err
+	}
+	return corrections, nil
+}
+
+func (r *dataExportRepository) GetTransactions(ctx context.Context, userID string) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *dataExportRepository) GetPaymentEvents(ctx context.Context, userID string) ([]entities.PaymentEvent, error) {
+	var events []entities.PaymentEvent
+	if err := r.db.WithContext(ctx).
+		Where("transaction_id IN (?)", r.db.Unscoped().Model(&entities.Transaction{}).Select("id").Where("user_id = ?", userID)).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *dataExportRepository) GetSessions(ctx context.Context, userID string) ([]entities.Session, error) {
+	var sessions []entities.Session
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *dataExportRepository) GetIdentities(ctx context.Context, userID string) ([]entities.UserIdentity, error) {
+	var identities []entities.UserIdentity
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ?", userID).
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// GetActivity returns the audit rows about the user and what they own. What
+// the user did to others, as staff, is those others' data and stays out.
+func (r *dataExportRepository) GetActivity(ctx context.Context, userID string) ([]entities.AuditLog, error) {
+	foodItems := r.db.Unscoped().Model(&entities.FoodItem{}).Select("id::text").Where("user_id = ?", userID)
+	transactions := r.db.Unscoped().Model(&entities.Transaction{}).Select("id::text").Where("user_id = ?", userID)
+
+	var logs []entities.AuditLog
+	if err := r.db.WithContext(ctx).
+		Where("target_type = ? AND target_id = ?", domain.AuditTargetUser, userID).
+		Or("target_type = ? AND target_id IN (?)", domain.AuditTargetFoodItem, foodItems).
+		Or("target_type = ? AND target_id IN (?)", domain.AuditTargetTransaction, transactions).
+		Order("id ASC").
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,585 @@
+package dataexport
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/internal/utils/mailing"
+	"Go-Starter-Template/internal/utils/storage"
+	"Go-Starter-Template/pkg/audit"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"html/template"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"time"
+)
+
+type (
+	DataExportService interface {
+		RequestExport(ctx context.Context, userID string) (domain.DataExportResponse, error)
+		GetExports(ctx context.Context, userID string) ([]domain.DataExportResponse, error)
+		GetDownloadLink(ctx context.Context, userID string, exportID string) (domain.DataExportLinkResponse, error)
+		ProcessPendingExports(ctx context.Context) error
+		PurgeExpiredExports(ctx context.Context) error
+	}
+
+	dataExportService struct {
+		dataExportRepository DataExportRepository
+		auditService         audit.AuditService
+		blobStore            storage.BlobStore
+		linkTTL              time.Duration
+		retention            time.Duration
+	}
+)
+
+const (
+	// maxLinkTTL is the longest lifetime S3 accepts for a presigned URL.
+	maxLinkTTL = 7 * 24 * time.Hour
+	// staleExportAfter hands an export to another worker when the one that
+	// claimed it has not finished by then.
+	staleExportAfter = 30 * time.Minute
+	exportFolder     = "data-exports"
+)
+
+func NewDataExportService(dataExportRepository DataExportRepository, auditService audit.AuditService, blobStore storage.BlobStore) DataExportService {
+	linkTTL := utils.GetDurationConfig("DATA_EXPORT_LINK_TTL", 48*time.Hour)
+	if linkTTL > maxLinkTTL {
+		linkTTL = maxLinkTTL
+	}
+	return &dataExportService{
+		dataExportRepository: dataExportRepository,
+		auditService:         auditService,
+		blobStore:            blobStore,
+		linkTTL:              linkTTL,
+		retention:            utils.GetDurationConfig("DATA_EXPORT_RETENTION", 7*24*time.Hour),
+	}
+}
+
+func (s *dataExportService) RequestExport(ctx context.Context, userID string) (domain.DataExportResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return domain.DataExportResponse{}, domain.ErrParseUUID
+	}
+
+	active, err := s.dataExportRepository.HasActiveExport(ctx, userID)
+	if err != nil {
+		return domain.DataExportResponse{}, err
+	}
+	if active {
+		return domain.DataExportResponse{}, domain.ErrDataExportInProgress
+	}
+
+	export := entities.DataExport{
+		UserID: userUUID,
+		Status: domain.DataExportStatusPending,
+	}
+	if err := s.dataExportRepository.CreateExport(ctx, &export); err != nil {
+		return domain.DataExportResponse{}, err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserDataExported,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		After:      map[string]string{"export_id": export.ID.String()},
+	})
+
+	// Start right away instead of waiting for the next worker tick, the
+	// worker still picks it up if this instance goes away.
+	go func() {
+		if err := s.ProcessPendingExports(context.Background()); err != nil {
+			log.Printf("data export: %v", err)
+		}
+	}()
+
+	return toDataExportResponse(export), nil
+}
+
+func (s *dataExportService) GetExports(ctx context.Context, userID string) ([]domain.DataExportResponse, error) {
+	exports, err := s.dataExportRepository.GetExportsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.DataExportResponse, 0, len(exports))
+	for _, export := range exports {
+		res = append(res, toDataExportResponse(export))
+	}
+	return res, nil
+}
+
+func (s *dataExportService) GetDownloadLink(ctx context.Context, userID string, exportID string) (domain.DataExportLinkResponse, error) {
+	export, err := s.dataExportRepository.GetExportByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.DataExportLinkResponse{}, domain.ErrDataExportNotFound
+		}
+		return domain.DataExportLinkResponse{}, err
+	}
+	if export.UserID.String() != userID {
+		return domain.DataExportLinkResponse{}, domain.ErrDataExportNotFound
+	}
+	if export.Status != domain.DataExportStatusReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return domain.DataExportLinkResponse{}, domain.ErrDataExportNotReady
+	}
+
+	return s.presign(*export)
+}
+
+// ProcessPendingExports builds archives until the queue is empty.
+func (s *dataExportService) ProcessPendingExports(ctx context.Context) error {
+	for {
+		export, err := s.dataExportRepository.ClaimNextExport(ctx, time.Now().Add(-staleExportAfter))
+		if err != nil {
+			return err
+		}
+		if export == nil {
+			return nil
+		}
+
+		if err := s.processExport(ctx, *export); err != nil {
+			log.Printf("data export %s: %v", export.ID, err)
+			if err := s.dataExportRepository.MarkFailed(ctx, export.ID.String(), err.Error()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *dataExportService) PurgeExpiredExports(ctx context.Context) error {
+	exports, err := s.dataExportRepository.GetExpiredExports(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.ObjectKey != "" {
+			if err := s.blobStore.DeleteFile(export.ObjectKey); err != nil {
+				log.Printf("data export %s: delete archive: %v", export.ID, err)
+				continue
+			}
+		}
+		if err := s.dataExportRepository.MarkExpired(ctx, export.ID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *dataExportService) processExport(ctx context.Context, export entities.DataExport) error {
+	userID := export.UserID.String()
+	user, err := s.dataExportRepository.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive, err := s.buildArchive(ctx, *user)
+	if err != nil {
+		return err
+	}
+
+	objectKey := fmt.Sprintf("%s/%s/%s.zip", exportFolder, userID, export.ID)
+	if _, err := s.blobStore.UploadBytes(objectKey, archive, "application/zip"); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.retention)
+	if err := s.dataExportRepository.MarkReady(ctx, export.ID.String(), objectKey, int64(len(archive)), expiresAt); err != nil {
+		return err
+	}
+
+	export.ObjectKey = objectKey
+	export.ExpiresAt = &expiresAt
+	link, err := s.presign(export)
+	if err != nil {
+		return err
+	}
+
+	// The archive is ready either way, the user can still fetch it from
+	// the API if the mail does not go out.
+	if err := sendExportReadyMail(*user, link, expiresAt); err != nil {
+		log.Printf("data export %s: send mail: %v", export.ID, err)
+	}
+	return nil
+}
+
+// presign issues a link that never outlives the archive it points to.
+func (s *dataExportService) presign(export entities.DataExport) (domain.DataExportLinkResponse, error) {
+	ttl := s.linkTTL
+	if remaining := time.Until(*export.ExpiresAt); remaining < ttl {
+		ttl = remaining
+	}
+
+	downloadName := fmt.Sprintf("foodia-data-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	url, err := s.blobStore.PresignGetURL(export.ObjectKey, ttl, downloadName)
+	if err != nil {
+		return domain.DataExportLinkResponse{}, err
+	}
+	return domain.DataExportLinkResponse{
+		URL:       url,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+type exportProfile struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Contact         string     `json:"contact"`
+	ProfilePicture  string     `json:"profile_picture"`
+	Role            string     `json:"role"`
+	Roles           []string   `json:"roles,omitempty"`
+	Subscribe       bool       `json:"subscribe"`
+	Verified        bool       `json:"verified"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`
+	SuspendedReason string     `json:"suspended_reason,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// exportActivity is an audit log row. When the change was made by someone
+// else only their role is given, who they are and where they connected from
+// is not the user's data.
+type exportActivity struct {
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	ByYou      bool            `json:"by_you"`
+	ActorRole  string          `json:"actor_role,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// exportPaymentEvent is a payment event, with the same treatment of staff as
+// exportActivity: Source says who made the change, not which of them.
+type exportPaymentEvent struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	Source        string    `json:"source"`
+	ByYou         bool      `json:"by_you"`
+	Type          string    `json:"type"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	Amount        int64     `json:"amount"`
+	Reference     string    `json:"reference,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (s *dataExportService) buildArchive(ctx context.Context, user entities.User) ([]byte, error) {
+	userID := user.ID.String()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	profile := exportProfile{
+		ID:              user.ID,
+		Name:            user.Name,
+		Username:        user.Username,
+		Email:           user.Email,
+		Contact:         user.Contact,
+		ProfilePicture:  user.ProfilePicture,
+		Role:            user.Role,
+		Subscribe:       user.Subscribe,
+		Verified:        user.Verified,
+		MFAEnabled:      user.MFAEnabled,
+		SuspendedAt:     user.SuspendedAt,
+		SuspendedReason: user.SuspendedReason,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+	for _, role := range user.Roles {
+		profile.Roles = append(profile.Roles, role.Name)
+	}
+	if err := writeJSON(zw, "profile.json", profile); err != nil {
+		return nil, err
+	}
+
+	foodItems, err := s.dataExportRepository.GetFoodItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "food_items.json", foodItems); err != nil {
+		return nil, err
+	}
+	foodRows := [][]string{{"id", "name", "quantity", "unit_measure", "expiry_date", "is_packaged", "status", "added_manually", "receipt_scan_id", "created_at", "deleted_at"}}
+	for _, item := range foodItems {
+		scanID := ""
+		if item.ReceiptScanID != nil {
+			scanID = *item.ReceiptScanID
+		}
+		deletedAt := ""
+		if item.DeletedAt.Valid {
+			deletedAt = item.DeletedAt.Time.Format(time.RFC3339)
+		}
+		foodRows = append(foodRows, []string{
+			item.ID.String(), item.Name, strconv.Itoa(item.Quantity), item.UnitMeasure,
+			item.ExpiryDate.Format("2006-01-02"), strconv.FormatBool(item.IsPackaged), item.Status,
+			strconv.FormatBool(item.AddedManually), scanID, item.CreatedAt.Format(time.RFC3339), deletedAt,
+		})
+	}
+	if err := writeCSV(zw, "food_items.csv", foodRows); err != nil {
+		return nil, err
+	}
+
+	scans, err := s.dataExportRepository.GetReceiptScans(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "receipt_scans.json", scans); err != nil {
+		return nil, err
+	}
+	for _, scan := range scans {
+		var objectKeys, names []string
+		for _, page := range scan.Pages {
+			ext := ".jpg"
+			if page.ContentType == "application/pdf" {
+				ext = ".pdf"
+			}
+			objectKeys = append(objectKeys, page.ObjectKey)
+			names = append(names, fmt.Sprintf("receipts/%s-%d%s", scan.ID, page.Number, ext))
+		}
+		// scans from before multi-page receipts only have their image
+		if len(objectKeys) == 0 && scan.ImageURL != "" {
+			objectKey := s.blobStore.GetObjectKeyFromLink(scan.ImageURL)
+			objectKeys = append(objectKeys, objectKey)
+			names = append(names, "receipts/"+scan.ID.String()+path.Ext(objectKey))
+		}
+
+		for i, objectKey := range objectKeys {
+			name := names[i]
+			image, err := s.blobStore.GetFile(objectKey)
+			if err != nil {
+				// A missing image should not cost the user the rest of the export.
+				log.Printf("data export: receipt %s image %s: %v", scan.ID, objectKey, err)
+				continue
+			}
+			if err := writeFile(zw, name, image); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	corrections, err := s.dataExportRepository.GetReceiptCorrections(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "receipt_corrections.json", corrections); err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.dataExportRepository.GetTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "transactions.json", transactions); err != nil {
+		return nil, err
+	}
+	transactionRows := [][]string{{"id", "invoice", "order_id", "status", "amount", "refunded_amount", "gateway", "paid_at", "created_at"}}
+	for _, transaction := range transactions {
+		paidAt := ""
+		if transaction.PaidAt != nil {
+			paidAt = transaction.PaidAt.Format(time.RFC3339)
+		}
+		transactionRows = append(transactionRows, []string{
+			transaction.ID.String(), transaction.Invoice, transaction.OrderID, transaction.Status,
+			strconv.FormatInt(transaction.Amount, 10), strconv.FormatInt(transaction.RefundedAmount, 10),
+			transaction.Gateway, paidAt, transaction.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSV(zw, "transactions.csv", transactionRows); err != nil {
+		return nil, err
+	}
+
+	paymentEvents, err := s.dataExportRepository.GetPaymentEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	paymentEventRows := make([]exportPaymentEvent, 0, len(paymentEvents))
+	for _, event := range paymentEvents {
+		paymentEventRows = append(paymentEventRows, toExportPaymentEvent(event, user.ID))
+	}
+	if err := writeJSON(zw, "payment_events.json", paymentEventRows); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.dataExportRepository.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "sessions.json", sessions); err != nil {
+		return nil, err
+	}
+
+	identities, err := s.dataExportRepository.GetIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeJSON(zw, "linked_accounts.json", identities); err != nil {
+		return nil, err
+	}
+
+	activity, err := s.dataExportRepository.GetActivity(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	activityRows := make([]exportActivity, 0, len(activity))
+	for _, l := range activity {
+		activityRows = append(activityRows, toExportActivity(l, user.ID))
+	}
+	if err := writeJSON(zw, "activity.json", activityRows); err != nil {
+		return nil, err
+	}
+
+	if err := writeFile(zw, "README.txt", []byte(exportReadme(user))); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func exportReadme(user entities.User) string {
+	return fmt.Sprintf(`Foodia personal data export
+Account: %s (%s)
+Generated: %s
+
+profile.json            your account details
+food_items.json/.csv    every food item you added, including deleted ones
+receipt_scans.json      receipt scans and their OCR results
+receipts/               the receipt images and PDFs you uploaded, named by scan id and page
+receipt_corrections.json  changes you made to scanned items before saving them
+transactions.json/.csv  subscription payments
+payment_events.json     every status change on those payments
+sessions.json           devices and browsers that signed in to your account
+linked_accounts.json    external sign in providers linked to your account
+activity.json           changes made to your account, by you or by our staff
+
+Foodia does not keep notification history or a separate consumption
+history, so there are no files for them. Your passwords and two factor
+secrets are never exported.
+`, user.Username, user.Email, time.Now().UTC().Format(time.RFC3339))
+}
+
+func writeFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(zw, name, data)
+}
+
+func writeCSV(zw *zip.Writer, name string, rows [][]string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return writeFile(zw, name, buf.Bytes())
+}
+
+func sendExportReadyMail(user entities.User, link domain.DataExportLinkResponse, expiresAt time.Time) error {
+	readHtml, err := os.ReadFile("internal/utils/mailing/template/data_export_ready.html")
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, map[string]any{
+		"Name":          user.Name,
+		"DownloadLink":  link.URL,
+		"LinkExpiresAt": link.ExpiresAt.UTC().Format("2 January 2006 15:04 MST"),
+		"ExpiresAt":     expiresAt.UTC().Format("2 January 2006"),
+	}); err != nil {
+		return err
+	}
+	return mailing.SendMail(user.Email, "Your Foodia data export is ready", strMail.String())
+}
+
+func toDataExportResponse(export entities.DataExport) domain.DataExportResponse {
+	return domain.DataExportResponse{
+		ID:          export.ID.String(),
+		Status:      export.Status,
+		SizeBytes:   export.SizeBytes,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
+
+// toExportActivity keeps the IP address and user agent only when the actor is
+// the user the export is for.
+func toExportActivity(l entities.AuditLog, userID uuid.UUID) exportActivity {
+	activity := exportActivity{
+		Action:     l.Action,
+		TargetType: l.TargetType,
+		TargetID:   l.TargetID,
+		ByYou:      l.ActorID != nil && *l.ActorID == userID,
+		ActorRole:  l.ActorRole,
+		CreatedAt:  l.CreatedAt,
+	}
+	if l.Before != "" {
+		activity.Before = json.RawMessage(l.Before)
+	}
+	if l.After != "" {
+		activity.After = json.RawMessage(l.After)
+	}
+	if activity.ByYou {
+		activity.IPAddress = l.IPAddress
+		activity.UserAgent = l.UserAgent
+	}
+	return activity
+}
+
+// toExportPaymentEvent drops the note of a change staff made, it is written
+// for the books and not to the user.
+func toExportPaymentEvent(event entities.PaymentEvent, userID uuid.UUID) exportPaymentEvent {
+	exported := exportPaymentEvent{
+		TransactionID: event.TransactionID,
+		Source:        event.Source,
+		ByYou:         event.ActorID != nil && *event.ActorID == userID,
+		Type:          event.Type,
+		FromStatus:    event.FromStatus,
+		ToStatus:      event.ToStatus,
+		Amount:        event.Amount,
+		Reference:     event.Reference,
+		CreatedAt:     event.CreatedAt,
+	}
+	if event.ActorID == nil || exported.ByYou {
+		exported.Note = event.Note
+	}
+	return exported
+}
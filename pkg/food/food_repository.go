@@ -4,6 +4,7 @@ import (
 	"Go-Starter-Template/entities"
 	"context"
 	"errors"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
 )
@@ -19,10 +20,19 @@ type (
 		MarkFoodItemAsDamaged(ctx context.Context, id string) error
 		GetDashboardStats(ctx context.Context, userID string) (map[string]interface{}, error)
 
+		// Trash related
+		GetTrashedFoodItems(ctx context.Context, userID string, page, limit int) ([]*entities.FoodItem, int64, error)
+		GetTrashedFoodItemByID(ctx context.Context, id string) (*entities.FoodItem, error)
+		RestoreFoodItem(ctx context.Context, id string) error
+		PurgeFoodItem(ctx context.Context, id string) error
+		GetFoodItemsTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*entities.FoodItem, error)
+
 		// Receipt scanning related
 		CreateReceiptScan(ctx context.Context, receiptScan *entities.ReceiptScan) error
 		GetReceiptScanByID(ctx context.Context, id string) (*entities.ReceiptScan, error)
 		UpdateReceiptScan(ctx context.Context, receiptScan *entities.ReceiptScan) error
+		GetReceiptScansSince(ctx context.Context, userID string, since time.Time) ([]*entities.ReceiptScan, error)
+		CompleteReceiptScan(ctx context.Context, scanID uuid.UUID, foodItems []*entities.FoodItem, corrections []*entities.ReceiptCorrection) error
 	}
 
 	foodRepository struct {
@@ -80,6 +90,57 @@ func (r *foodRepository) GetFoodItems(ctx context.Context, userID string, status
 	return foodItems, count, nil
 }
 
+func (r *foodRepository) GetTrashedFoodItems(ctx context.Context, userID string, page, limit int) ([]*entities.FoodItem, int64, error) {
+	var foodItems []*entities.FoodItem
+	var count int64
+
+	offset := (page - 1) * limit
+
+	query := r.db.WithContext(ctx).Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+
+	if err := query.Model(&entities.FoodItem{}).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Offset(offset).Limit(limit).Order("deleted_at desc").Find(&foodItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return foodItems, count, nil
+}
+
+func (r *foodRepository) GetTrashedFoodItemByID(ctx context.Context, id string) (*entities.FoodItem, error) {
+	var foodItem entities.FoodItem
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&foodItem).Error; err != nil {
+		return nil, err
+	}
+	return &foodItem, nil
+}
+
+func (r *foodRepository) RestoreFoodItem(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&entities.FoodItem{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+func (r *foodRepository) PurgeFoodItem(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&entities.FoodItem{}).Error
+}
+
+func (r *foodRepository) GetFoodItemsTrashedBefore(ctx context.Context, before time.Time, limit int) ([]*entities.FoodItem, error) {
+	var foodItems []*entities.FoodItem
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at asc").
+		Limit(limit).
+		Find(&foodItems).Error; err != nil {
+		return nil, err
+	}
+	return foodItems, nil
+}
+
 func (r *foodRepository) GetFoodItemsByExpiryRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]*entities.FoodItem, error) {
 	var foodItems []*entities.FoodItem
 
@@ -162,7 +223,9 @@ func (r *foodRepository) CreateReceiptScan(ctx context.Context, receiptScan *ent
 
 func (r *foodRepository) GetReceiptScanByID(ctx context.Context, id string) (*entities.ReceiptScan, error) {
 	var receiptScan entities.ReceiptScan
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&receiptScan).Error; err != nil {
+	if err := r.db.WithContext(ctx).
+		Preload("Pages", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Where("id = ?", id).First(&receiptScan).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
@@ -174,3 +237,46 @@ func (r *foodRepository) GetReceiptScanByID(ctx context.Context, id string) (*en
 func (r *foodRepository) UpdateReceiptScan(ctx context.Context, receiptScan *entities.ReceiptScan) error {
 	return r.db.WithContext(ctx).Save(receiptScan).Error
 }
+
+// GetReceiptScansSince lists the scans of the user created after since,
+// newest first. Failed scans are left out, uploading one again is a retry.
+func (r *foodRepository) GetReceiptScansSince(ctx context.Context, userID string, since time.Time) ([]*entities.ReceiptScan, error) {
+	var scans []*entities.ReceiptScan
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND created_at >= ? AND status <> ?", userID, since, "Failed").
+		Order("created_at DESC").
+		Find(&scans).Error; err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// CompleteReceiptScan saves the items taken from a scan and marks it
+// completed. The corrections are only kept by the save that completes a
+// processed scan, a concurrent or repeated save would count them twice.
+func (r *foodRepository) CompleteReceiptScan(ctx context.Context, scanID uuid.UUID, foodItems []*entities.FoodItem, corrections []*entities.ReceiptCorrection) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, foodItem := range foodItems {
+			if err := tx.Create(foodItem).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&entities.ReceiptScan{}).
+			Where("id = ? AND status = ?", scanID, "Processed").
+			Update("status", "Completed")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Model(&entities.ReceiptScan{}).
+				Where("id = ?", scanID).
+				Update("status", "Completed").Error
+		}
+
+		if len(corrections) == 0 {
+			return nil
+		}
+		return tx.Create(&corrections).Error
+	})
+}
@@ -4,10 +4,16 @@ import (
 	"Go-Starter-Template/domain"
 	"Go-Starter-Template/entities"
 	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/internal/utils/imaging"
+	"Go-Starter-Template/internal/utils/itemmatch"
 	"Go-Starter-Template/internal/utils/storage"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/upload"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,10 +21,12 @@ import (
 	"gorm.io/gorm"
 	"io"
 	"log"
+	"math"
+	"math/bits"
 	"mime/multipart"
 	"net/http"
-	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -28,6 +36,7 @@ type (
 		AddFoodItem(ctx context.Context, req domain.AddFoodItemRequest, userID string) (domain.AddFoodItemResponse, error)
 		UpdateFoodItem(ctx context.Context, id string, req domain.UpdateFoodItemRequest, userID string) error
 		DeleteFoodItem(ctx context.Context, id string, userID string) error
+		RemoveFoodItem(ctx context.Context, id string) error
 		GetFoodItems(ctx context.Context, userID string, status string, page, limit int) ([]domain.FoodItemResponse, int64, error)
 		GetFoodItemByID(ctx context.Context, id string, userID string) (domain.FoodItemResponse, error)
 		UploadFoodImage(ctx context.Context, req domain.UploadFoodImageRequest, userID string) error
@@ -37,18 +46,31 @@ type (
 		MarkAsDamaged(ctx context.Context, req domain.MarkAsDamagedRequest, userID string) error
 		GetDashboardStats(ctx context.Context, userID string) (domain.DashboardStatsResponse, error)
 		DetectFoodAge(ctx context.Context, imageFile *multipart.FileHeader) (domain.GeminiResponse, error)
+		GetTrash(ctx context.Context, userID string, page, limit int) ([]domain.TrashedFoodItemResponse, int64, error)
+		RestoreFoodItem(ctx context.Context, id string, userID string) error
+		PurgeFoodItem(ctx context.Context, id string, userID string) error
+		PurgeExpiredTrash(ctx context.Context) error
 	}
 
 	foodService struct {
 		foodRepository FoodRepository
-		s3             storage.AwsS3
+		auditService   audit.AuditService
+		blobStore      storage.BlobStore
+		uploadService  upload.UploadService
+		trashRetention time.Duration
+		// duplicateWindow is how far back uploaded receipts are compared
+		duplicateWindow time.Duration
 	}
 )
 
-func NewFoodService(foodRepository FoodRepository, s3 storage.AwsS3) FoodService {
+func NewFoodService(foodRepository FoodRepository, auditService audit.AuditService, blobStore storage.BlobStore, uploadService upload.UploadService) FoodService {
 	return &foodService{
-		foodRepository: foodRepository,
-		s3:             s3,
+		foodRepository:  foodRepository,
+		auditService:    auditService,
+		blobStore:       blobStore,
+		uploadService:   uploadService,
+		trashRetention:  utils.GetDurationConfig("FOOD_TRASH_RETENTION", 30*24*time.Hour),
+		duplicateWindow: utils.GetDurationConfig("RECEIPT_DUPLICATE_WINDOW", 90*24*time.Hour),
 	}
 }
 
@@ -149,14 +171,160 @@ func (s *foodService) DeleteFoodItem(ctx context.Context, id string, userID stri
 		return domain.ErrUnauthorizedAccess
 	}
 
-	if foodItem.ImageURL != "" {
-		objectKey := s.s3.GetObjectKeyFromLink(foodItem.ImageURL)
-		if objectKey != "" {
-			_ = s.s3.DeleteFile(objectKey)
+	return s.deleteFoodItem(ctx, foodItem)
+}
+
+// RemoveFoodItem deletes any user's item, callers need food:delete:any.
+func (s *foodService) RemoveFoodItem(ctx context.Context, id string) error {
+	foodItem, err := s.foodRepository.GetFoodItemByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrFoodItemNotFound
 		}
+		return err
+	}
+
+	return s.deleteFoodItem(ctx, foodItem)
+}
+
+// deleteFoodItem moves the item to the trash. Its image stays in the bucket
+// until the item is purged so a restore gets it back too.
+func (s *foodService) deleteFoodItem(ctx context.Context, foodItem *entities.FoodItem) error {
+	if err := s.foodRepository.DeleteFoodItem(ctx, foodItem.ID.String()); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditFoodItemDeleted,
+		TargetType: domain.AuditTargetFoodItem,
+		TargetID:   foodItem.ID.String(),
+		Before:     foodItem,
+	})
+	return nil
+}
+
+func (s *foodService) GetTrash(ctx context.Context, userID string, page, limit int) ([]domain.TrashedFoodItemResponse, int64, error) {
+	foodItems, count, err := s.foodRepository.GetTrashedFoodItems(ctx, userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response := make([]domain.TrashedFoodItemResponse, 0, len(foodItems))
+	for _, item := range foodItems {
+		response = append(response, domain.TrashedFoodItemResponse{
+			FoodItemResponse: domain.FoodItemResponse{
+				ID:          item.ID.String(),
+				Name:        item.Name,
+				Quantity:    item.Quantity,
+				UnitMeasure: item.UnitMeasure,
+				ExpiryDate:  item.ExpiryDate,
+				IsPackaged:  item.IsPackaged,
+				Status:      item.Status,
+				ImageURL:    s.blobStore.SignedLink(item.ImageURL),
+				Thumbnails:  s.thumbnails(item.ImageURL, item.HasThumbnails),
+				CreatedAt:   item.CreatedAt,
+			},
+			DeletedAt: item.DeletedAt.Time,
+			PurgeAt:   item.DeletedAt.Time.Add(s.trashRetention),
+		})
+	}
+
+	return response, count, nil
+}
+
+func (s *foodService) RestoreFoodItem(ctx context.Context, id string, userID string) error {
+	foodItem, err := s.getTrashedFoodItem(ctx, id, userID)
+	if err != nil {
+		return err
 	}
 
-	return s.foodRepository.DeleteFoodItem(ctx, id)
+	if err := s.foodRepository.RestoreFoodItem(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditFoodItemRestored,
+		TargetType: domain.AuditTargetFoodItem,
+		TargetID:   foodItem.ID.String(),
+	})
+	return nil
+}
+
+// PurgeFoodItem permanently deletes an item the user already moved to the
+// trash.
+func (s *foodService) PurgeFoodItem(ctx context.Context, id string, userID string) error {
+	foodItem, err := s.getTrashedFoodItem(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	return s.purgeFoodItem(ctx, foodItem)
+}
+
+// PurgeExpiredTrash permanently deletes items that sat in the trash longer
+// than the retention period.
+func (s *foodService) PurgeExpiredTrash(ctx context.Context) error {
+	const batchSize = 100
+	before := time.Now().Add(-s.trashRetention)
+
+	for {
+		foodItems, err := s.foodRepository.GetFoodItemsTrashedBefore(ctx, before, batchSize)
+		if err != nil {
+			return err
+		}
+
+		purged := 0
+		for _, foodItem := range foodItems {
+			if err := s.purgeFoodItem(ctx, foodItem); err != nil {
+				log.Printf("food trash purge %s: %v", foodItem.ID, err)
+				continue
+			}
+			purged++
+		}
+
+		// a short batch is the last one, a batch that purged nothing would
+		// only be fetched again
+		if len(foodItems) < batchSize || purged == 0 {
+			return nil
+		}
+	}
+}
+
+func (s *foodService) getTrashedFoodItem(ctx context.Context, id string, userID string) (*entities.FoodItem, error) {
+	foodItem, err := s.foodRepository.GetTrashedFoodItemByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrFoodItemNotFound
+		}
+		return nil, err
+	}
+
+	if foodItem.UserID.String() != userID {
+		return nil, domain.ErrUnauthorizedAccess
+	}
+	return foodItem, nil
+}
+
+// purgeFoodItem removes the image before the row, if the bucket is
+// unreachable the item stays in the trash and is retried later.
+func (s *foodService) purgeFoodItem(ctx context.Context, foodItem *entities.FoodItem) error {
+	if objectKey := s.blobStore.GetObjectKeyFromLink(foodItem.ImageURL); objectKey != "" {
+		for _, key := range append([]string{objectKey}, imaging.VariantKeys(objectKey)...) {
+			if err := s.blobStore.DeleteFile(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.foodRepository.PurgeFoodItem(ctx, foodItem.ID.String()); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditFoodItemPurged,
+		TargetType: domain.AuditTargetFoodItem,
+		TargetID:   foodItem.ID.String(),
+	})
+	return nil
 }
 
 func (s *foodService) GetFoodItems(ctx context.Context, userID string, status string, page, limit int) ([]domain.FoodItemResponse, int64, error) {
@@ -175,7 +343,8 @@ func (s *foodService) GetFoodItems(ctx context.Context, userID string, status st
 			ExpiryDate:  item.ExpiryDate,
 			IsPackaged:  item.IsPackaged,
 			Status:      item.Status,
-			ImageURL:    item.ImageURL,
+			ImageURL:    s.blobStore.SignedLink(item.ImageURL),
+			Thumbnails:  s.thumbnails(item.ImageURL, item.HasThumbnails),
 			CreatedAt:   item.CreatedAt,
 		})
 	}
@@ -204,7 +373,8 @@ func (s *foodService) GetFoodItemByID(ctx context.Context, id string, userID str
 		ExpiryDate:  foodItem.ExpiryDate,
 		IsPackaged:  foodItem.IsPackaged,
 		Status:      foodItem.Status,
-		ImageURL:    foodItem.ImageURL,
+		ImageURL:    s.blobStore.SignedLink(foodItem.ImageURL),
+		Thumbnails:  s.thumbnails(foodItem.ImageURL, foodItem.HasThumbnails),
 		CreatedAt:   foodItem.CreatedAt,
 	}, nil
 }
@@ -222,28 +392,24 @@ func (s *foodService) UploadFoodImage(ctx context.Context, req domain.UploadFood
 		return domain.ErrUnauthorizedAccess
 	}
 
-	fileName := fmt.Sprintf("food-item-%s", foodItem.ID.String())
-	var objectKey string
-	var uploadErr error
-
-	if foodItem.ImageURL != "" {
-		existingKey := s.s3.GetObjectKeyFromLink(foodItem.ImageURL)
-		if existingKey != "" {
-			objectKey, uploadErr = s.s3.UpdateFile(existingKey, req.Image, storage.AllowImage...)
-		} else {
-			objectKey, uploadErr = s.s3.UploadFile(fileName, req.Image, "food-items", storage.AllowImage...)
-		}
-	} else {
-		objectKey, uploadErr = s.s3.UploadFile(fileName, req.Image, "food-items", storage.AllowImage...)
+	file, err := s.uploadService.Resolve(ctx, userID, domain.UploadPurposeFoodImage, req.Image, req.ObjectKey)
+	if err != nil {
+		return err
 	}
 
-	if uploadErr != nil {
-		return uploadErr
+	objectKey := s.blobStore.GetObjectKeyFromLink(foodItem.ImageURL)
+	if objectKey == "" {
+		objectKey = fmt.Sprintf("food-items/food-item-%s", foodItem.ID.String())
 	}
+	if err := s.uploadService.Store(objectKey, file); err != nil {
+		return err
+	}
+	s.uploadService.Release(req.ObjectKey)
 
-	foodItem.ImageURL = s.s3.GetPublicLinkKey(objectKey)
+	foodItem.ImageURL = objectKey
+	foodItem.HasThumbnails = len(file.Variants) > 0
 
-	geminiResponse, err := s.DetectFoodAge(ctx, req.Image)
+	geminiResponse, err := s.detectFoodAge(ctx, file.Vision.Data, file.Vision.ContentType)
 	if err != nil {
 		fmt.Printf("Error analyzing food image with Gemini: %v\n", err)
 	} else {
@@ -256,17 +422,15 @@ func (s *foodService) UploadFoodImage(ctx context.Context, req domain.UploadFood
 }
 
 func (s *foodService) DetectFoodAge(ctx context.Context, imageFile *multipart.FileHeader) (domain.GeminiResponse, error) {
-	file, err := imageFile.Open()
-	if err != nil {
-		return domain.GeminiResponse{}, err
-	}
-	defer file.Close()
-
-	fileData, err := io.ReadAll(file)
+	// a file sent here is not kept, so there is no owner to check
+	file, err := s.uploadService.Resolve(ctx, "", domain.UploadPurposeFoodImage, imageFile, "")
 	if err != nil {
 		return domain.GeminiResponse{}, err
 	}
+	return s.detectFoodAge(ctx, file.Vision.Data, file.Vision.ContentType)
+}
 
+func (s *foodService) detectFoodAge(ctx context.Context, fileData []byte, mimeType string) (domain.GeminiResponse, error) {
 	base64Image := base64.StdEncoding.EncodeToString(fileData)
 
 	geminiAPIKey := utils.GetConfig("GEMINI_API_KEY")
@@ -279,24 +443,6 @@ func (s *foodService) DetectFoodAge(ctx context.Context, imageFile *multipart.Fi
 		return domain.GeminiResponse{}, fmt.Errorf("GEMINI_MODEL environment variable not set")
 	}
 
-	mimeType := imageFile.Header.Get("Content-Type")
-	if mimeType == "" {
-		mimeType = "image/jpeg"
-
-		filename := imageFile.Filename
-		ext := strings.ToLower(filepath.Ext(filename))
-		switch ext {
-		case ".png":
-			mimeType = "image/png"
-		case ".jpg", ".jpeg":
-			mimeType = "image/jpeg"
-		case ".gif":
-			mimeType = "image/gif"
-		case ".webp":
-			mimeType = "image/webp"
-		}
-	}
-
 	geminiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", geminiModel, geminiAPIKey)
 
 	requestBody := map[string]interface{}{
@@ -429,41 +575,134 @@ func (s *foodService) DetectFoodAge(ctx context.Context, imageFile *multipart.Fi
 }
 
 func (s *foodService) UploadReceipt(ctx context.Context, req domain.UploadReceiptRequest, userID string) (domain.UploadReceiptResponse, error) {
-	items, err := s.processReceiptWithGemini(ctx, req.ReceiptImage)
+	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "failed to parse") && len(items) > 0 {
-			log.Printf("Warning: %v", err)
-		} else {
-			return domain.UploadReceiptResponse{}, fmt.Errorf("error processing receipt with Gemini: %w", err)
-		}
+		return domain.UploadReceiptResponse{}, domain.ErrParseUUID
 	}
 
-	scanID := uuid.New()
+	files := req.ReceiptImages
+	if req.ReceiptImage != nil {
+		files = append([]*multipart.FileHeader{req.ReceiptImage}, files...)
+	}
+	objectKeys := req.ObjectKeys
+	if req.ObjectKey != "" {
+		objectKeys = append([]string{req.ObjectKey}, objectKeys...)
+	}
+	pages, err := s.uploadService.ResolvePages(ctx, userID, domain.UploadPurposeReceipt, files, objectKeys)
+	if err != nil {
+		return domain.UploadReceiptResponse{}, err
+	}
 
-	return domain.UploadReceiptResponse{
-		ScanID: scanID.String(),
+	scanID := uuid.New()
+	scan := &entities.ReceiptScan{
+		ID:     scanID,
+		UserID: userUUID,
 		Status: "Processed",
-		Items:  items,
-	}, nil
-}
+	}
+	scan.ContentHash, scan.PerceptualHash = receiptHashes(pages)
 
-func (s *foodService) processReceiptWithGemini(ctx context.Context, receiptImage *multipart.FileHeader) ([]map[string]interface{}, error) {
-	file, err := receiptImage.Open()
+	// kept for a second look once the header is read
+	previous, err := s.foodRepository.GetReceiptScansSince(ctx, userID, time.Now().Add(-s.duplicateWindow))
 	if err != nil {
-		return nil, fmt.Errorf("error opening file: %w", err)
+		return domain.UploadReceiptResponse{}, err
+	}
+	if match, exact := duplicateReceiptScan(previous, scan); match != nil {
+		if exact && !req.AllowDuplicate {
+			return domain.UploadReceiptResponse{DuplicateOf: match.ID.String()}, domain.ErrDuplicateReceipt
+		}
+		scan.DuplicateOfID = &match.ID
 	}
-	defer file.Close()
 
-	fileData, err := io.ReadAll(file)
+	vision := make([]storage.File, 0, len(pages))
+	for i, page := range pages {
+		objectKey := fmt.Sprintf("receipts/%s/%s/%d", userID, scanID.String(), i+1)
+		if err := s.uploadService.Store(objectKey, page); err != nil {
+			return domain.UploadReceiptResponse{}, err
+		}
+		scan.Pages = append(scan.Pages, &entities.ReceiptPage{
+			ID:            uuid.New(),
+			ReceiptScanID: scanID,
+			Number:        i + 1,
+			ObjectKey:     objectKey,
+			ContentType:   page.File.ContentType,
+			HasThumbnails: len(page.Variants) > 0,
+		})
+		vision = append(vision, page.Vision)
+	}
+	// the first page stands in for the receipt where one image is expected
+	scan.ImageURL = scan.Pages[0].ObjectKey
+	scan.HasThumbnails = scan.Pages[0].HasThumbnails
+	for _, objectKey := range objectKeys {
+		s.uploadService.Release(objectKey)
+	}
+
+	header, items, err := ReadReceipt(ctx, vision)
 	if err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		scan.Status = "Failed"
+		scan.OcrResults = err.Error()
+		if createErr := s.foodRepository.CreateReceiptScan(ctx, scan); createErr != nil {
+			log.Printf("failed to save receipt scan %s: %v", scanID, createErr)
+		}
+		return domain.UploadReceiptResponse{}, fmt.Errorf("error processing receipt with Gemini: %w", err)
+	}
+
+	scan.MerchantName = header.MerchantName
+	scan.MerchantBranch = header.MerchantBranch
+	scan.PurchasedAt = header.PurchasedAt
+	scan.Subtotal = header.Subtotal
+	scan.Tax = header.Tax
+	scan.Discount = header.Discount
+	scan.Total = header.Total
+	scan.ItemsTotal = header.ItemsTotal
+	scan.NeedsReview = header.NeedsReview
+	if scan.DuplicateOfID == nil {
+		if match := sameReceiptHeader(previous, scan); match != nil {
+			scan.DuplicateOfID = &match.ID
+		}
 	}
 
-	base64Image := base64.StdEncoding.EncodeToString(fileData)
+	ocrResults, err := json.Marshal(items)
+	if err != nil {
+		return domain.UploadReceiptResponse{}, err
+	}
+	scan.OcrResults = string(ocrResults)
+	if err := s.foodRepository.CreateReceiptScan(ctx, scan); err != nil {
+		return domain.UploadReceiptResponse{}, err
+	}
+
+	res := domain.UploadReceiptResponse{
+		ScanID:  scanID.String(),
+		Status:  "Processed",
+		Receipt: header,
+		Items:   items,
+	}
+	if scan.DuplicateOfID != nil {
+		res.DuplicateOf = scan.DuplicateOfID.String()
+	}
+	return res, nil
+}
+
+// ReadReceipt reads the pages of one receipt with the vision provider and
+// returns its header and food items the way UploadReceipt stores them.
+// cmd/ocreval runs it over a golden dataset.
+func ReadReceipt(ctx context.Context, pages []storage.File) (domain.ReceiptHeader, []map[string]interface{}, error) {
+	header, items, err := processReceiptWithGemini(ctx, pages)
+	if err != nil {
+		return domain.ReceiptHeader{}, nil, err
+	}
 
+	items = mergeReceiptItems(items)
+	header.ItemsTotal, header.NeedsReview = reconcileReceipt(header, items)
+	return header, completeReceiptItems(items, header.PurchasedAt), nil
+}
+
+// processReceiptWithGemini reads the header and every line off the pages of
+// one receipt, sent together so the model sees where consecutive photos
+// overlap. The lines are returned as read, see completeReceiptItems.
+func processReceiptWithGemini(ctx context.Context, pages []storage.File) (domain.ReceiptHeader, []map[string]interface{}, error) {
 	geminiAPIKey := utils.GetConfig("GEMINI_API_KEY")
 	if geminiAPIKey == "" {
-		return nil, errors.New("GEMINI_API_KEY not configured")
+		return domain.ReceiptHeader{}, nil, errors.New("GEMINI_API_KEY not configured")
 	}
 
 	geminiModel := utils.GetConfig("GEMINI_MODEL")
@@ -471,80 +710,80 @@ func (s *foodService) processReceiptWithGemini(ctx context.Context, receiptImage
 		geminiModel = "gemini-pro-vision"
 	}
 
-	mimeType := receiptImage.Header.Get("Content-Type")
-	if mimeType == "" {
-		mimeType = "image/jpeg"
+	geminiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", geminiModel, geminiAPIKey)
 
-		filename := receiptImage.Filename
-		ext := strings.ToLower(filepath.Ext(filename))
-		switch ext {
-		case ".png":
-			mimeType = "image/png"
-		case ".jpg", ".jpeg":
-			mimeType = "image/jpeg"
-		case ".webp":
-			mimeType = "image/webp"
-		}
+	prompt := "You are an expert in analyzing receipt images. This is a grocery or food receipt. Extract the purchase details and every line item.\n\n"
+	if len(pages) > 1 {
+		prompt += fmt.Sprintf("The receipt comes as %d images or PDF pages, in order from the top of the receipt. Consecutive photos may overlap, list an item shown in the overlap only once.\n\n", len(pages))
+	}
+
+	parts := []map[string]interface{}{
+		{
+			"text": prompt +
+				"Return your analysis as a valid, well-formed JSON object with these fields:\n" +
+				"- merchant_name: the store name (string)\n" +
+				"- merchant_branch: the branch or outlet, empty if not shown (string)\n" +
+				"- purchase_date: the date of purchase (YYYY-MM-DD format), empty if not shown\n" +
+				"- purchase_time: the time of purchase (HH:MM format, 24 hours), empty if not shown\n" +
+				"- subtotal, tax, discount, total: the amounts printed on the receipt, null if not shown (number)\n" +
+				"- items: every line item, food or not, in the order printed\n\n" +
+				"Each item is an object with these fields:\n" +
+				"- name: the item name (string)\n" +
+				"- price: the price shown on receipt (string)\n" +
+				"- line_total: the amount charged for the line after any discount on that line (number)\n" +
+				"- is_food: whether it is food or drink (boolean)\n" +
+				"- estimated_age: typical shelf life in days (number)\n" +
+				"- unit_measure: the most likely unit (string - e.g., 'kg', 'pcs')\n" +
+				"- is_packaged: whether it's packaged (boolean)\n" +
+				"- category: food category (string)\n" +
+				"- confidence: your confidence (number between 0-1)\n" +
+				"- page: the image or page the item was read from, counting from 1 (number)\n\n" +
+				"Write every amount as a plain number, without currency symbols or thousands separators and with a dot before any decimals. A discount on the whole receipt goes in discount, not in items.\n\n" +
+				"IMPORTANT: Your response must be ONLY the valid JSON object - do not include any explanations, notes, or markdown formatting. Make sure your JSON is properly closed with brackets and is syntactically valid.",
+		},
+	}
+	for _, page := range pages {
+		parts = append(parts, map[string]interface{}{
+			"inline_data": map[string]interface{}{
+				"mime_type": page.ContentType,
+				"data":      base64.StdEncoding.EncodeToString(page.Data),
+			},
+		})
 	}
-
-	geminiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", geminiModel, geminiAPIKey)
 
 	requestBody := map[string]interface{}{
 		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]interface{}{
-					{
-						// Modify the prompt in the Gemini API request:
-						"text": "You are an expert in analyzing receipt images. This is a grocery or food receipt. Extract the food items and their details.\n\n" +
-							"Return your analysis as a valid, well-formed JSON array, where each object has these fields:\n" +
-							"- name: the food item name (string)\n" +
-							"- price: the price shown on receipt (string)\n" +
-							"- estimated_age: typical shelf life in days (number)\n" +
-							"- expiry_date: calculated expiry date based on today (YYYY-MM-DD format)\n" +
-							"- unit_measure: the most likely unit (string - e.g., 'kg', 'pcs')\n" +
-							"- is_packaged: whether it's packaged (boolean)\n" +
-							"- category: food category (string)\n" +
-							"- confidence: your confidence (number between 0-1)\n\n" +
-							"IMPORTANT: Your response must be ONLY the valid JSON array - do not include any explanations, notes, or markdown formatting. Make sure your JSON is properly closed with brackets and is syntactically valid.",
-					},
-					{
-						"inline_data": map[string]interface{}{
-							"mime_type": mimeType,
-							"data":      base64Image,
-						},
-					},
-				},
-			},
+			{"parts": parts},
 		},
 		"generationConfig": map[string]interface{}{
 			"temperature":     0.1,
 			"topP":            0.8,
 			"topK":            40,
-			"maxOutputTokens": 1024,
+			"maxOutputTokens": min(1024*len(pages), 8192),
 		},
 	}
 
 	requestJSON, err := json.Marshal(requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("error marshaling request: %w", err)
 	}
 
 	httpClient := &http.Client{Timeout: 60 * time.Second} // Longer timeout for OCR
 	req, err := http.NewRequestWithContext(ctx, "POST", geminiURL, bytes.NewBuffer(requestJSON))
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("error creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error calling Gemini API: %w", err)
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("error calling Gemini API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Gemini API error: %s - %s", resp.Status, string(bodyBytes))
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("Gemini API error: %s - %s", resp.Status, string(bodyBytes))
 	}
 
 	var geminiResp struct {
@@ -558,21 +797,15 @@ func (s *foodService) processReceiptWithGemini(ctx context.Context, receiptImage
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
-		return nil, fmt.Errorf("error decoding Gemini response: %w", err)
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("error decoding Gemini response: %w", err)
 	}
 
 	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return nil, errors.New("no content in Gemini response")
+		return domain.ReceiptHeader{}, nil, errors.New("no content in Gemini response")
 	}
 
 	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
 
-	jsonPattern := regexp.MustCompile(`(?s)\[\s*\{.*\}\s*\]`)
-	matches := jsonPattern.FindString(responseText)
-	if matches != "" {
-		responseText = matches
-	}
-
 	responseText = strings.TrimSpace(responseText)
 	if strings.HasPrefix(responseText, "```json") {
 		responseText = strings.TrimPrefix(responseText, "```json")
@@ -582,73 +815,36 @@ func (s *foodService) processReceiptWithGemini(ctx context.Context, receiptImage
 		responseText = strings.TrimSuffix(responseText, "```")
 	}
 	responseText = strings.TrimSpace(responseText)
-
-	if strings.HasPrefix(responseText, "[") && !strings.HasSuffix(responseText, "]") {
-		lastBraceIndex := strings.LastIndex(responseText, "}")
-		if lastBraceIndex > 0 {
-			responseText = responseText[:lastBraceIndex+1] + "\n]"
-		}
+	if match := regexp.MustCompile(`(?s)\{.*\}`).FindString(responseText); match != "" {
+		responseText = match
 	}
 
-	var items []map[string]interface{}
-	if err := json.Unmarshal([]byte(responseText), &items); err != nil {
-		items = []map[string]interface{}{}
-
-		objPattern := regexp.MustCompile(`\{[^{}]*\}`)
-		objMatches := objPattern.FindAllString(responseText, -1)
-
-		for _, objStr := range objMatches {
-			var item map[string]interface{}
-			if jsonErr := json.Unmarshal([]byte(objStr), &item); jsonErr == nil {
+	var receipt map[string]interface{}
+	if err := json.Unmarshal([]byte(responseText), &receipt); err == nil {
+		var items []map[string]interface{}
+		lines, _ := receipt["items"].([]interface{})
+		for _, line := range lines {
+			if item, ok := line.(map[string]interface{}); ok {
 				items = append(items, item)
 			}
 		}
-
-		if len(items) == 0 {
-			return nil, fmt.Errorf("failed to parse Gemini JSON response: %w, raw response: %s", err, responseText)
-		}
+		return receiptHeader(receipt), items, nil
 	}
 
-	now := time.Now()
-	for i, item := range items {
-		if _, ok := item["name"]; !ok {
-			item["name"] = "Unknown Item"
-		}
-
-		if _, ok := item["estimated_age"]; !ok {
-			item["estimated_age"] = 7
-		}
-
-		if _, ok := item["expiry_date"]; !ok {
-			estimatedAge, ok := item["estimated_age"].(float64)
-			if !ok {
-				estimatedAge = 7
-			}
-
-			expiryDate := now.AddDate(0, 0, int(estimatedAge))
-			item["expiry_date"] = expiryDate.Format("2006-01-02")
-		}
-
-		if _, ok := item["unit_measure"]; !ok {
-			item["unit_measure"] = "pcs"
-		}
-
-		if _, ok := item["is_packaged"]; !ok {
-			item["is_packaged"] = true
-		}
-
-		if _, ok := item["confidence"]; !ok {
-			item["confidence"] = 0.7
-		}
-
-		if _, ok := item["quantity"]; !ok {
-			item["quantity"] = 1
+	// A reply cut off at maxOutputTokens loses the header, whatever items
+	// made it through complete are still worth having.
+	var items []map[string]interface{}
+	objPattern := regexp.MustCompile(`\{[^{}]*\}`)
+	for _, objStr := range objPattern.FindAllString(responseText, -1) {
+		var item map[string]interface{}
+		if jsonErr := json.Unmarshal([]byte(objStr), &item); jsonErr == nil {
+			items = append(items, item)
 		}
-
-		items[i] = item
 	}
-
-	return items, nil
+	if len(items) == 0 {
+		return domain.ReceiptHeader{}, nil, fmt.Errorf("failed to parse Gemini JSON response, raw response: %s", responseText)
+	}
+	return domain.ReceiptHeader{}, items, nil
 }
 
 func (s *foodService) getFoodAgeEstimationFromGemini(ctx context.Context, foodName string) (domain.GeminiResponse, error) {
@@ -789,9 +985,39 @@ func (s *foodService) GetReceiptScanResult(ctx context.Context, scanID string, u
 
 	result := map[string]interface{}{
 		"id":         scan.ID.String(),
-		"image_url":  scan.ImageURL,
+		"image_url":  s.blobStore.SignedLink(scan.ImageURL),
 		"status":     scan.Status,
 		"created_at": scan.CreatedAt,
+		"receipt": domain.ReceiptHeader{
+			MerchantName:   scan.MerchantName,
+			MerchantBranch: scan.MerchantBranch,
+			PurchasedAt:    scan.PurchasedAt,
+			Subtotal:       scan.Subtotal,
+			Tax:            scan.Tax,
+			Discount:       scan.Discount,
+			Total:          scan.Total,
+			ItemsTotal:     scan.ItemsTotal,
+			NeedsReview:    scan.NeedsReview,
+		},
+	}
+	if thumbnails := s.thumbnails(scan.ImageURL, scan.HasThumbnails); thumbnails != nil {
+		result["thumbnails"] = thumbnails
+	}
+	pages := make([]map[string]interface{}, 0, len(scan.Pages))
+	for _, page := range scan.Pages {
+		p := map[string]interface{}{
+			"number":       page.Number,
+			"content_type": page.ContentType,
+			"url":          s.blobStore.SignedLink(page.ObjectKey),
+		}
+		if thumbnails := s.thumbnails(page.ObjectKey, page.HasThumbnails); thumbnails != nil {
+			p["thumbnails"] = thumbnails
+		}
+		pages = append(pages, p)
+	}
+	result["pages"] = pages
+	if scan.DuplicateOfID != nil {
+		result["duplicate_of"] = scan.DuplicateOfID.String()
 	}
 
 	if scan.Status == "Processed" && scan.OcrResults != "" {
@@ -840,6 +1066,7 @@ func (s *foodService) SaveScannedItems(ctx context.Context, req domain.SaveScann
 		return domain.ErrParseUUID
 	}
 
+	foodItems := make([]*entities.FoodItem, 0, len(req.Items))
 	for _, item := range req.Items {
 		// Parse expiry date from string
 		expiryDate, err := time.Parse("2006-01-02", item.ExpiryDate)
@@ -865,18 +1092,19 @@ func (s *foodService) SaveScannedItems(ctx context.Context, req domain.SaveScann
 			ReceiptScanID: &scanIDStr,
 		}
 
-		// Save food item to database
-		if err := s.foodRepository.AddFoodItem(ctx, foodItem); err != nil {
-			return err
-		}
+		foodItems = append(foodItems, foodItem)
 	}
 
-	scan.Status = "Completed"
-	if err := s.foodRepository.UpdateReceiptScan(ctx, scan); err != nil {
-		return err
+	// saving again adds to the first save, the items are no longer the ones read
+	var corrections []*entities.ReceiptCorrection
+	if scan.Status == "Processed" {
+		var suggested []map[string]interface{}
+		if err := json.Unmarshal([]byte(scan.OcrResults), &suggested); err == nil {
+			corrections = receiptCorrections(scan, suggested, req.Items)
+		}
 	}
 
-	return nil
+	return s.foodRepository.CompleteReceiptScan(ctx, scan.ID, foodItems, corrections)
 }
 
 func (s *foodService) MarkAsDamaged(ctx context.Context, req domain.MarkAsDamagedRequest, userID string) error {
@@ -913,6 +1141,332 @@ func (s *foodService) GetDashboardStats(ctx context.Context, userID string) (dom
 	}, nil
 }
 
+// thumbnails links the imaging variants stored next to the image at ref.
+func (s *foodService) thumbnails(ref string, hasThumbnails bool) map[string]string {
+	objectKey := s.blobStore.GetObjectKeyFromLink(ref)
+	if !hasThumbnails || objectKey == "" {
+		return nil
+	}
+
+	thumbnails := make(map[string]string, len(imaging.Variants))
+	for _, variant := range imaging.Variants {
+		thumbnails[variant.Name] = s.blobStore.SignedLink(imaging.VariantKey(objectKey, variant.Name))
+	}
+	return thumbnails
+}
+
+// mergeReceiptItems drops the items read twice where consecutive photos of
+// a receipt overlap. An item counts as the same when name and price match,
+// and it is dropped while its page has it no more often than the page before.
+// A purchase repeated across the overlap is lost that way, the lesser evil
+// next to every overlapping line turning up twice.
+func mergeReceiptItems(items []map[string]interface{}) []map[string]interface{} {
+	counts := make(map[string]map[int]int)
+	merged := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		key := itemmatch.Normalize(fmt.Sprint(item["name"])) + "|" + strings.Join(strings.Fields(fmt.Sprint(item["price"])), "")
+		page := 1
+		if number, ok := item["page"].(float64); ok && number >= 1 {
+			page = int(number)
+		}
+
+		if counts[key] == nil {
+			counts[key] = make(map[int]int)
+		}
+		counts[key][page]++
+		if counts[key][page] <= counts[key][page-1] {
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// receiptHeader picks the purchase details out of the object the model
+// returned.
+func receiptHeader(receipt map[string]interface{}) domain.ReceiptHeader {
+	merchantName, _ := receipt["merchant_name"].(string)
+	merchantBranch, _ := receipt["merchant_branch"].(string)
+	purchaseDate, _ := receipt["purchase_date"].(string)
+	purchaseTime, _ := receipt["purchase_time"].(string)
+
+	return domain.ReceiptHeader{
+		MerchantName:   strings.TrimSpace(merchantName),
+		MerchantBranch: strings.TrimSpace(merchantBranch),
+		PurchasedAt:    purchasedAt(purchaseDate, purchaseTime),
+		Subtotal:       receiptAmount(receipt["subtotal"]),
+		Tax:            receiptAmount(receipt["tax"]),
+		Discount:       receiptAmount(receipt["discount"]),
+		Total:          receiptAmount(receipt["total"]),
+	}
+}
+
+// purchasedAt parses the date and time printed on a receipt, nil when there
+// is no date or it lies ahead, which can only be a misread.
+func purchasedAt(date, clock string) *time.Time {
+	date, clock = strings.TrimSpace(date), strings.TrimSpace(clock)
+	t, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", date, time.Local); err != nil {
+			return nil
+		}
+	}
+	if t.After(time.Now().Add(24 * time.Hour)) {
+		return nil
+	}
+	return &t
+}
+
+// receiptAmount reads an amount the model may have sent as a number or, in
+// spite of the prompt, as a string. Anything else is nil.
+func receiptAmount(value interface{}) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case string:
+		amount, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		return &amount
+	}
+	return nil
+}
+
+// reconcileReceipt sums the line totals and checks the sum against the
+// amounts printed on the receipt. Stores differ in whether prices include
+// tax and whether the discount is taken off before the subtotal, so any
+// reading that adds up counts. A receipt without printed amounts cannot
+// be checked and is not flagged, one with a line that has no amount is.
+func reconcileReceipt(header domain.ReceiptHeader, items []map[string]interface{}) (*float64, bool) {
+	var sum float64
+	for _, item := range items {
+		amount := receiptAmount(item["line_total"])
+		if amount == nil {
+			return nil, header.Subtotal != nil || header.Total != nil
+		}
+		sum += *amount
+	}
+
+	var expected []float64
+	if header.Subtotal != nil {
+		expected = append(expected, *header.Subtotal)
+	}
+	if header.Total != nil {
+		tax, discount := 0.0, 0.0
+		if header.Tax != nil {
+			tax = *header.Tax
+		}
+		if header.Discount != nil {
+			discount = math.Abs(*header.Discount)
+		}
+		expected = append(expected, *header.Total, *header.Total-tax, *header.Total+discount, *header.Total-tax+discount)
+	}
+	if len(expected) == 0 {
+		return &sum, false
+	}
+
+	for _, amount := range expected {
+		// half a percent covers rounding on each line
+		if math.Abs(sum-amount) <= math.Max(0.01, math.Abs(amount)*0.005) {
+			return &sum, false
+		}
+	}
+	return &sum, true
+}
+
+// completeReceiptItems keeps the food among the lines of a receipt and
+// fills in what the model left out. Expiry dates count from the purchase,
+// from now when the receipt has no date.
+func completeReceiptItems(items []map[string]interface{}, purchasedAt *time.Time) []map[string]interface{} {
+	bought := time.Now()
+	if purchasedAt != nil {
+		bought = *purchasedAt
+	}
+
+	food := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if isFood, ok := item["is_food"].(bool); ok && !isFood {
+			continue
+		}
+
+		if _, ok := item["name"]; !ok {
+			item["name"] = "Unknown Item"
+		}
+
+		estimatedAge, ok := item["estimated_age"].(float64)
+		if !ok {
+			estimatedAge = 7
+			item["estimated_age"] = 7
+		}
+		item["expiry_date"] = bought.AddDate(0, 0, int(estimatedAge)).Format("2006-01-02")
+
+		if _, ok := item["unit_measure"]; !ok {
+			item["unit_measure"] = "pcs"
+		}
+
+		if _, ok := item["is_packaged"]; !ok {
+			item["is_packaged"] = true
+		}
+
+		if _, ok := item["confidence"]; !ok {
+			item["confidence"] = 0.7
+		}
+
+		if _, ok := item["quantity"]; !ok {
+			item["quantity"] = 1
+		}
+
+		food = append(food, item)
+	}
+	return food
+}
+
+// receiptCorrections lists what the user changed in the items suggested for
+// a receipt before saving them. A saved item pairs with the suggestion its
+// OcrIndex points at and otherwise with the one of the most similar name.
+func receiptCorrections(scan *entities.ReceiptScan, suggested []map[string]interface{}, saved []domain.ScannedItemRequest) []*entities.ReceiptCorrection {
+	names := make([]string, len(suggested))
+	quantities := make([]int, len(suggested))
+	for i, item := range suggested {
+		names[i] = fmt.Sprint(item["name"])
+		quantities[i] = 1
+		if quantity, ok := item["quantity"].(float64); ok {
+			quantities[i] = int(quantity)
+		}
+	}
+
+	pairs := make(map[int]int)
+	used := make(map[int]bool)
+	var unpaired []int
+	for i, item := range saved {
+		if item.OcrIndex != nil && *item.OcrIndex >= 0 && *item.OcrIndex < len(suggested) && !used[*item.OcrIndex] {
+			pairs[i] = *item.OcrIndex
+			used[*item.OcrIndex] = true
+			continue
+		}
+		unpaired = append(unpaired, i)
+	}
+	var unpairedNames, leftNames []string
+	var left []int
+	for _, i := range unpaired {
+		unpairedNames = append(unpairedNames, saved[i].Name)
+	}
+	for j := range suggested {
+		if !used[j] {
+			left = append(left, j)
+			leftNames = append(leftNames, names[j])
+		}
+	}
+	for a, b := range itemmatch.Match(unpairedNames, leftNames) {
+		pairs[unpaired[a]] = left[b]
+		used[left[b]] = true
+	}
+
+	correction := func(kind string) *entities.ReceiptCorrection {
+		return &entities.ReceiptCorrection{
+			ID:            uuid.New(),
+			ReceiptScanID: scan.ID,
+			UserID:        scan.UserID,
+			Kind:          kind,
+		}
+	}
+	var corrections []*entities.ReceiptCorrection
+	for i, item := range saved {
+		j, ok := pairs[i]
+		if !ok {
+			c := correction(domain.ReceiptCorrectionItemAdded)
+			c.CorrectedName, c.CorrectedQuantity = item.Name, item.Quantity
+			corrections = append(corrections, c)
+			continue
+		}
+		if itemmatch.Normalize(item.Name) != itemmatch.Normalize(names[j]) {
+			c := correction(domain.ReceiptCorrectionNameChanged)
+			c.OriginalName, c.CorrectedName = names[j], item.Name
+			corrections = append(corrections, c)
+		}
+		if item.Quantity != quantities[j] {
+			c := correction(domain.ReceiptCorrectionQuantityChanged)
+			c.OriginalName, c.CorrectedName = names[j], item.Name
+			c.OriginalQuantity, c.CorrectedQuantity = quantities[j], item.Quantity
+			corrections = append(corrections, c)
+		}
+	}
+	for j := range suggested {
+		if !used[j] {
+			c := correction(domain.ReceiptCorrectionItemRemoved)
+			c.OriginalName, c.OriginalQuantity = names[j], quantities[j]
+			corrections = append(corrections, c)
+		}
+	}
+	return corrections
+}
+
+// maxDHashDistance is how many bits the dHash of two photos of the same
+// receipt may differ in. Receipts are all dark print on white paper, so it
+// is kept tight.
+const maxDHashDistance = 4
+
+// receiptHashes fingerprints the pages of a receipt: the SHA-256 of their
+// stored bytes, which the same file always comes out as, and the dHash of
+// the first page when it is a picture.
+func receiptHashes(pages []upload.Upload) (string, string) {
+	h := sha256.New()
+	for _, page := range pages {
+		h.Write(page.File.Data)
+	}
+
+	perceptualHash := ""
+	if pages[0].File.ContentType == imaging.ContentType {
+		perceptualHash = fmt.Sprintf("%016x", pages[0].DHash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), perceptualHash
+}
+
+// duplicateReceiptScan finds the scan among previous that scan repeats,
+// exact when it has the very same pages and not when it only looks alike.
+func duplicateReceiptScan(previous []*entities.ReceiptScan, scan *entities.ReceiptScan) (*entities.ReceiptScan, bool) {
+	for _, p := range previous {
+		if p.ContentHash == scan.ContentHash {
+			return p, true
+		}
+	}
+
+	hash, err := strconv.ParseUint(scan.PerceptualHash, 16, 64)
+	if err != nil {
+		return nil, false
+	}
+	for _, p := range previous {
+		other, err := strconv.ParseUint(p.PerceptualHash, 16, 64)
+		if err != nil {
+			continue
+		}
+		if bits.OnesCount64(hash^other) <= maxDHashDistance {
+			return p, false
+		}
+	}
+	return nil, false
+}
+
+// sameReceiptHeader finds the scan among previous from the same store at
+// the same time for the same total, a receipt photographed again.
+func sameReceiptHeader(previous []*entities.ReceiptScan, scan *entities.ReceiptScan) *entities.ReceiptScan {
+	if scan.MerchantName == "" || scan.PurchasedAt == nil || scan.Total == nil {
+		return nil
+	}
+	for _, p := range previous {
+		if p.PurchasedAt == nil || p.Total == nil {
+			continue
+		}
+		if strings.EqualFold(p.MerchantName, scan.MerchantName) &&
+			p.PurchasedAt.Equal(*scan.PurchasedAt) &&
+			math.Abs(*p.Total-*scan.Total) < 0.005 {
+			return p
+		}
+	}
+	return nil
+}
+
 func determineStatus(expiryDate time.Time) string {
 	now := time.Now()
 
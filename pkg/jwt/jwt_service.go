@@ -7,76 +7,91 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"log"
+	"strings"
 	"time"
 )
 
 type (
 	JWTService interface {
-		GenerateTokenUser(userId string, role string) string
+		GenerateTokenUser(userId string, role string, sessionID string, amr []string) string
 		ValidateToken(token string) (*jwt.Token, error)
 		GetUserIDByToken(token string) (string, string, error)
-		GenerateTokenForgetPassword(data map[string]any, duration time.Duration) (string, error)
-		ValidateTokenForgetPassword(token string) (jwt.MapClaims, error)
+		ParseTokenUser(token string) (*UserClaims, error)
+		GenerateTokenMFA(userID string, amr []string, duration time.Duration) (string, error)
+		ParseTokenMFA(token string) (*MFAClaims, error)
+		JWKS() JSONWebKeySet
 	}
 
-	jwtUserClaim struct {
-		UserID string `json:"user_id"`
-		Role   string `json:"role"`
+	UserClaims struct {
+		UserID    string   `json:"user_id"`
+		Role      string   `json:"role"`
+		SessionID string   `json:"sid"`
+		AMR       []string `json:"amr,omitempty"`
+		jwt.RegisteredClaims
+	}
+
+	// MFAClaims identify a user who passed the first factor and still has to
+	// answer the second one. They carry no user_id so they never pass as an
+	// access token.
+	MFAClaims struct {
+		Purpose string   `json:"purpose"`
+		AMR     []string `json:"amr"`
 		jwt.RegisteredClaims
 	}
 
 	jwtService struct {
-		secretKey string
-		issuer    string
+		keys           *keySet
+		issuer         string
+		accessTokenTTL time.Duration
 	}
 )
 
-func getSecretKey() string {
-	utils.LoadConfig()
-	secretKey := utils.GetConfig("JWT_SECRET")
-	return secretKey
+func getKeyFiles() []string {
+	var files []string
+	for _, file := range strings.Split(utils.GetConfig("JWT_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
 }
 
-func NewJWTService() JWTService {
-	return &jwtService{
-		secretKey: getSecretKey(),
-		issuer:    "FOODIA",
+func NewJWTService() (JWTService, error) {
+	utils.LoadConfig()
+	keys, err := loadKeySet(getKeyFiles(), utils.GetConfig("JWT_ACTIVE_KID"), utils.GetConfig("JWT_SECRET"))
+	if err != nil {
+		return nil, err
 	}
+
+	return &jwtService{
+		keys:           keys,
+		issuer:         "FOODIA",
+		accessTokenTTL: utils.GetDurationConfig("ACCESS_TOKEN_TTL", time.Minute*15),
+	}, nil
 }
 
-func (j *jwtService) GenerateTokenUser(userId string, role string) string {
-	claims := jwtUserClaim{
+func (j *jwtService) GenerateTokenUser(userId string, role string, sessionID string, amr []string) string {
+	claims := UserClaims{
 		userId,
 		role,
+		sessionID,
+		amr,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 120)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.accessTokenTTL)),
 			Issuer:    j.issuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tx, err := token.SignedString([]byte(j.secretKey))
+	tx, err := j.keys.sign(claims)
 	if err != nil {
 		log.Println(err)
 	}
 	return tx
 }
 
-func (j *jwtService) parseToken(t_ *jwt.Token) (any, error) {
-	if _, ok := t_.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
-	}
-	return []byte(j.secretKey), nil
-}
-
 func (j *jwtService) ValidateToken(token string) (*jwt.Token, error) {
-	return jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	return jwt.Parse(token, j.keys.keyFunc)
 }
 
 func (j *jwtService) GetUserIDByToken(token string) (string, string, error) {
@@ -101,31 +116,53 @@ func (j *jwtService) GetUserIDByToken(token string) (string, string, error) {
 	return id, role, nil
 }
 
-func (j *jwtService) GenerateTokenForgetPassword(data map[string]any, duration time.Duration) (string, error) {
-	claims := jwt.MapClaims{}
-
-	for key, value := range data {
-		claims[key] = value
+// ParseTokenUser validates an access token and returns its claims.
+func (j *jwtService) ParseTokenUser(token string) (*UserClaims, error) {
+	claims := new(UserClaims)
+	t_Token, err := jwt.ParseWithClaims(token, claims, j.keys.keyFunc)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, domain.ErrTokenExpired
+		}
+		return nil, domain.ErrTokenInvalid
 	}
+	if !t_Token.Valid || claims.UserID == "" {
+		return nil, domain.ErrTokenInvalid
+	}
+	return claims, nil
+}
 
-	claims["exp"] = time.Now().Add(duration).Unix()
-	claims["iat"] = time.Now().Unix()
-	claims["iss"] = j.issuer
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+func (j *jwtService) GenerateTokenMFA(userID string, amr []string, duration time.Duration) (string, error) {
+	claims := MFAClaims{
+		Purpose: "mfa",
+		AMR:     amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
+			Issuer:    j.issuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return j.keys.sign(claims)
 }
 
-func (j *jwtService) ValidateTokenForgetPassword(token string) (jwt.MapClaims, error) {
-	t_Token, err := j.ValidateToken(token)
+func (j *jwtService) ParseTokenMFA(token string) (*MFAClaims, error) {
+	claims := new(MFAClaims)
+	t_Token, err := jwt.ParseWithClaims(token, claims, j.keys.keyFunc)
 	if err != nil {
-		return jwt.MapClaims{}, domain.ErrTokenExpired
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, domain.ErrTokenExpired
+		}
+		return nil, domain.ErrTokenInvalid
 	}
-
-	if !t_Token.Valid {
-		return jwt.MapClaims{}, domain.ErrTokenInvalid
+	if !t_Token.Valid || claims.Purpose != "mfa" || claims.Subject == "" {
+		return nil, domain.ErrTokenInvalid
 	}
-
-	claims := t_Token.Claims.(jwt.MapClaims)
 	return claims, nil
 }
+
+// JWKS returns the public verification keys so other services can check
+// FOODIA tokens without sharing a secret. It is empty in HS256 mode.
+func (j *jwtService) JWKS() JSONWebKeySet {
+	return j.keys.jwks()
+}
@@ -0,0 +1,202 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrNoSigningKey      = errors.New("no jwt signing key configured")
+	ErrUnknownKeyID      = errors.New("unknown jwt key id")
+	ErrUnsupportedKeyPEM = errors.New("unsupported jwt key, expected an RSA or Ed25519 key")
+)
+
+type (
+	// JSONWebKey is the public half of a verification key as published in
+	// /.well-known/jwks.json (RFC 7517).
+	JSONWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+	}
+
+	JSONWebKeySet struct {
+		Keys []JSONWebKey `json:"keys"`
+	}
+
+	signingKey struct {
+		kid     string
+		method  jwt.SigningMethod
+		private crypto.Signer
+		public  crypto.PublicKey
+	}
+
+	// keySet holds every key tokens may be verified with. Only the active key
+	// signs; retired keys stay listed until the tokens they signed expire.
+	// When no key files are configured it falls back to HS256 with the secret.
+	keySet struct {
+		active *signingKey
+		keys   map[string]*signingKey
+		secret []byte
+	}
+)
+
+func loadKeySet(files []string, activeKID string, secret string) (*keySet, error) {
+	set := &keySet{
+		keys:   make(map[string]*signingKey),
+		secret: []byte(secret),
+	}
+
+	for _, file := range files {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := set.keys[key.kid]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.kid)
+		}
+		set.keys[key.kid] = key
+	}
+
+	if len(set.keys) == 0 {
+		if len(set.secret) == 0 {
+			return nil, ErrNoSigningKey
+		}
+		return set, nil
+	}
+
+	active, ok := set.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKeyID, activeKID)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("active jwt key %q has no private key", activeKID)
+	}
+	set.active = active
+	return set, nil
+}
+
+// loadKeyFile reads a private key (PKCS#1 or PKCS#8) or, for retired keys
+// that only verify, a PKIX public key. The key id is the file name up to the
+// first dot, so keys/2026-10.pem and keys/2026-10.pub.pem are both "2026-10".
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	kid, _, _ := strings.Cut(filepath.Base(path), ".")
+	key := &signingKey{kid: kid}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedKeyPEM)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedKeyPEM)
+	}
+	return key, nil
+}
+
+func (s *keySet) sign(claims jwt.Claims) (string, error) {
+	if s.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+
+	token := jwt.NewWithClaims(s.active.method, claims)
+	token.Header["kid"] = s.active.kid
+	return token.SignedString(s.active.private)
+}
+
+// keyFunc picks the verification key from the kid header and refuses tokens
+// whose alg does not match it, so an RSA public key can never be used as an
+// HMAC secret.
+func (s *keySet) keyFunc(token *jwt.Token) (any, error) {
+	if len(s.keys) == 0 {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return s.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+func (s *keySet) jwks() JSONWebKeySet {
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(kids))}
+	for _, kid := range kids {
+		key := s.keys[kid]
+		jwk := JSONWebKey{
+			Kid: kid,
+			Use: "sig",
+			Alg: key.method.Alg(),
+		}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
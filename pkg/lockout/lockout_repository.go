@@ -0,0 +1,75 @@
+package lockout
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type (
+	LockoutRepository interface {
+		GetThrottles(ctx context.Context, keys ...string) ([]entities.LoginThrottle, error)
+		RecordFailure(ctx context.Context, key string, window time.Duration) (*entities.LoginThrottle, error)
+		Lock(ctx context.Context, key string, until time.Time) error
+		Reset(ctx context.Context, key string) error
+		DeleteStale(ctx context.Context, before time.Time) error
+	}
+
+	lockoutRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewLockoutRepository(db *gorm.DB) LockoutRepository {
+	return &lockoutRepository{db: db}
+}
+
+func (r *lockoutRepository) GetThrottles(ctx context.Context, keys ...string) ([]entities.LoginThrottle, error) {
+	var throttles []entities.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// RecordFailure increments the counter in a single upsert. A failure after a
+// quiet period longer than window starts counting from one again.
+func (r *lockoutRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*entities.LoginThrottle, error) {
+	now := time.Now()
+	throttle := entities.LoginThrottle{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END", now.Add(-window)),
+				"last_failure_at": now,
+			}),
+		}, clause.Returning{}).
+		Create(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.LoginThrottle{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (r *lockoutRepository) Reset(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&entities.LoginThrottle{}).Error
+}
+
+func (r *lockoutRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, time.Now()).
+		Delete(&entities.LoginThrottle{}).Error
+}
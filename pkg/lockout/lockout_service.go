@@ -0,0 +1,119 @@
+package lockout
+
+import (
+	"Go-Starter-Template/domain"
+	"context"
+	"strings"
+	"time"
+)
+
+type (
+	// Policy locks a key for BaseLock once Threshold failures happened within
+	// Window, doubling with every further failure up to MaxLock.
+	Policy struct {
+		Threshold int
+		BaseLock  time.Duration
+		MaxLock   time.Duration
+		Window    time.Duration
+	}
+
+	LockoutService interface {
+		Check(ctx context.Context, email string, ip string) error
+		RegisterFailure(ctx context.Context, email string, ip string) (bool, error)
+		RegisterSuccess(ctx context.Context, email string) error
+		Unlock(ctx context.Context, email string) error
+		PurgeStale(ctx context.Context) error
+	}
+
+	lockoutService struct {
+		lockoutRepository LockoutRepository
+		accountPolicy     Policy
+		ipPolicy          Policy
+	}
+)
+
+var (
+	DefaultAccountPolicy = Policy{Threshold: 5, BaseLock: time.Minute, MaxLock: time.Hour, Window: 24 * time.Hour}
+	DefaultIPPolicy      = Policy{Threshold: 30, BaseLock: time.Minute, MaxLock: time.Hour, Window: time.Hour}
+)
+
+func NewLockoutService(lockoutRepository LockoutRepository, accountPolicy Policy, ipPolicy Policy) LockoutService {
+	return &lockoutService{
+		lockoutRepository: lockoutRepository,
+		accountPolicy:     accountPolicy,
+		ipPolicy:          ipPolicy,
+	}
+}
+
+// Check fails while the account or the client address is locked. It runs
+// before the password is compared so a locked account gives nothing away.
+func (s *lockoutService) Check(ctx context.Context, email string, ip string) error {
+	throttles, err := s.lockoutRepository.GetThrottles(ctx, accountKey(email), ipKey(ip))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			if strings.HasPrefix(throttle.Key, "ip:") {
+				return domain.ErrTooManyLoginAttempts
+			}
+			return domain.ErrAccountLocked
+		}
+	}
+	return nil
+}
+
+// RegisterFailure counts a failed login against both keys and reports
+// whether this failure is the one that locked the account, which is when the
+// unlock email goes out.
+func (s *lockoutService) RegisterFailure(ctx context.Context, email string, ip string) (bool, error) {
+	if _, err := s.registerFailure(ctx, ipKey(ip), s.ipPolicy); err != nil {
+		return false, err
+	}
+	failures, err := s.registerFailure(ctx, accountKey(email), s.accountPolicy)
+	if err != nil {
+		return false, err
+	}
+	return failures == s.accountPolicy.Threshold, nil
+}
+
+func (s *lockoutService) registerFailure(ctx context.Context, key string, policy Policy) (int, error) {
+	throttle, err := s.lockoutRepository.RecordFailure(ctx, key, policy.Window)
+	if err != nil {
+		return 0, err
+	}
+	if throttle.Failures < policy.Threshold {
+		return throttle.Failures, nil
+	}
+	return throttle.Failures, s.lockoutRepository.Lock(ctx, key, time.Now().Add(policy.lockFor(throttle.Failures)))
+}
+
+func (s *lockoutService) RegisterSuccess(ctx context.Context, email string) error {
+	return s.lockoutRepository.Reset(ctx, accountKey(email))
+}
+
+func (s *lockoutService) Unlock(ctx context.Context, email string) error {
+	return s.lockoutRepository.Reset(ctx, accountKey(email))
+}
+
+func (s *lockoutService) PurgeStale(ctx context.Context) error {
+	return s.lockoutRepository.DeleteStale(ctx, time.Now().Add(-s.accountPolicy.Window))
+}
+
+func (p Policy) lockFor(failures int) time.Duration {
+	lock := p.BaseLock
+	for i := p.Threshold; i < failures && lock < p.MaxLock; i++ {
+		lock *= 2
+	}
+	return min(lock, p.MaxLock)
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,162 @@
+package mfa
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type (
+	MFARepository interface {
+		GetUserByID(ctx context.Context, id string) (*entities.User, error)
+		SetSecret(ctx context.Context, userID string, encryptedSecret string) error
+		EnableMFA(ctx context.Context, userID string, step int64, codeHashes []string) error
+		DisableMFA(ctx context.Context, userID string) error
+		AdvanceStep(ctx context.Context, userID string, step int64) (bool, error)
+		ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error
+		UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error)
+		GetPolicies(ctx context.Context) ([]entities.MFAPolicy, error)
+		IsPolicyRequired(ctx context.Context, roles []string) (bool, error)
+		SavePolicy(ctx context.Context, policy *entities.MFAPolicy) error
+	}
+
+	mfaRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetUserByID(ctx context.Context, id string) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetSecret stores a new, not yet confirmed, secret. It is refused once MFA
+// is enabled so enrollment cannot silently replace a working authenticator.
+func (r *mfaRepository) SetSecret(ctx context.Context, userID string, encryptedSecret string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.User{}).
+		Where("id = ? AND mfa_enabled = ?", userID, false).
+		Updates(map[string]any{
+			"mfa_secret":    encryptedSecret,
+			"mfa_last_step": 0,
+		}).Error
+}
+
+func (r *mfaRepository) EnableMFA(ctx context.Context, userID string, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{
+				"mfa_enabled":   true,
+				"mfa_last_step": step,
+			}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *mfaRepository) DisableMFA(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]any{
+				"mfa_enabled":   false,
+				"mfa_secret":    "",
+				"mfa_last_step": 0,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error
+	})
+}
+
+// AdvanceStep records the time step of an accepted TOTP code. It only
+// succeeds for a step newer than the last one, so a code works once.
+func (r *mfaRepository) AdvanceStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.User{}).
+		Where("id = ? AND mfa_last_step < ?", userID, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID string, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) GetPolicies(ctx context.Context) ([]entities.MFAPolicy, error) {
+	var policies []entities.MFAPolicy
+	if err := r.db.WithContext(ctx).Order("role asc").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// IsPolicyRequired reports whether the policy of any of roles requires a
+// second factor.
+func (r *mfaRepository) IsPolicyRequired(ctx context.Context, roles []string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&entities.MFAPolicy{}).
+		Where("role IN ? AND required = ?", roles, true).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *mfaRepository) SavePolicy(ctx context.Context, policy *entities.MFAPolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "role"}},
+			DoUpdates: clause.AssignmentColumns([]string{"required", "updated_at"}),
+		}).
+		Create(policy).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string, codeHashes []string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]entities.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, entities.RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   id,
+			CodeHash: hash,
+		})
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,386 @@
+package mfa
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/jwt"
+	"Go-Starter-Template/pkg/session"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+	"image/png"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	issuer            = "FOODIA"
+	totpPeriod        = 30
+	challengeTTL      = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+type (
+	MFAService interface {
+		SignIn(ctx context.Context, user entities.User, client domain.SessionClient, amr []string) (domain.UserLoginResponse, error)
+		Verify(ctx context.Context, req domain.MFAVerifyRequest, client domain.SessionClient) (domain.UserLoginResponse, error)
+		Enroll(ctx context.Context, userID string) (domain.MFAEnrollResponse, error)
+		Enable(ctx context.Context, userID string, code string) (domain.MFARecoveryCodesResponse, error)
+		Disable(ctx context.Context, userID string, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (domain.MFARecoveryCodesResponse, error)
+		IsRequired(ctx context.Context, roles []string) (bool, error)
+		GetPolicies(ctx context.Context) ([]domain.MFAPolicyResponse, error)
+		SetPolicy(ctx context.Context, role string, req domain.MFAPolicyRequest) (domain.MFAPolicyResponse, error)
+	}
+
+	mfaService struct {
+		mfaRepository  MFARepository
+		sessionService session.SessionService
+		jwtService     jwt.JWTService
+		auditService   audit.AuditService
+	}
+)
+
+func NewMFAService(mfaRepository MFARepository, sessionService session.SessionService, jwtService jwt.JWTService, auditService audit.AuditService) MFAService {
+	return &mfaService{
+		mfaRepository:  mfaRepository,
+		sessionService: sessionService,
+		jwtService:     jwtService,
+		auditService:   auditService,
+	}
+}
+
+// SignIn finishes a first factor login. Users without MFA get a session right
+// away, the others get a short lived challenge token for Verify.
+func (s *mfaService) SignIn(ctx context.Context, user entities.User, client domain.SessionClient, amr []string) (domain.UserLoginResponse, error) {
+	if user.SuspendedAt != nil {
+		return domain.UserLoginResponse{}, domain.ErrAccountSuspended
+	}
+	if !user.MFAEnabled {
+		return s.sessionService.CreateSession(ctx, user, client, amr)
+	}
+
+	token, err := s.jwtService.GenerateTokenMFA(user.ID.String(), amr, challengeTTL)
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	return domain.UserLoginResponse{
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, req domain.MFAVerifyRequest, client domain.SessionClient) (domain.UserLoginResponse, error) {
+	claims, err := s.jwtService.ParseTokenMFA(req.MFAToken)
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+
+	user, err := s.getUser(ctx, claims.Subject)
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	if !user.MFAEnabled {
+		return domain.UserLoginResponse{}, domain.ErrMFANotEnabled
+	}
+	if err := s.checkCode(ctx, user, req.Code); err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+
+	if req.DeviceName != "" {
+		client.DeviceName = req.DeviceName
+	} else if client.DeviceName == "" {
+		client.DeviceName = client.UserAgent
+	}
+	return s.sessionService.CreateSession(ctx, *user, client, append(claims.AMR, domain.AMROTP))
+}
+
+func (s *mfaService) Enroll(ctx context.Context, userID string) (domain.MFAEnrollResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+	if user.MFAEnabled {
+		return domain.MFAEnrollResponse{}, domain.ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+
+	encrypted, err := utils.AESEncrypt(key.Secret())
+	if err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+	if err := s.mfaRepository.SetSecret(ctx, userID, encrypted); err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return domain.MFAEnrollResponse{}, err
+	}
+
+	return domain.MFAEnrollResponse{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     base64.StdEncoding.EncodeToString(qr.Bytes()),
+	}, nil
+}
+
+// Enable confirms enrollment with a code from the authenticator and returns
+// the recovery codes, which are never shown again.
+func (s *mfaService) Enable(ctx context.Context, userID string, code string) (domain.MFARecoveryCodesResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+	if user.MFAEnabled {
+		return domain.MFARecoveryCodesResponse{}, domain.ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return domain.MFARecoveryCodesResponse{}, domain.ErrMFANotEnrolled
+	}
+
+	step, err := s.matchTOTP(user, code)
+	if err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+	if err := s.mfaRepository.EnableMFA(ctx, userID, step, hashes); err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserMFAEnabled,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]any{"mfa_enabled": false},
+		After:      map[string]any{"mfa_enabled": true},
+	})
+	return domain.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID string, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return domain.ErrMFANotEnabled
+	}
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.mfaRepository.DisableMFA(ctx, userID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserMFADisabled,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]any{"mfa_enabled": true},
+		After:      map[string]any{"mfa_enabled": false},
+	})
+	return nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) (domain.MFARecoveryCodesResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+	if !user.MFAEnabled {
+		return domain.MFARecoveryCodesResponse{}, domain.ErrMFANotEnabled
+	}
+	if err := s.checkCode(ctx, user, code); err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return domain.MFARecoveryCodesResponse{}, err
+	}
+	return domain.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// IsRequired reports whether a user holding roles needs a second factor,
+// which is the case as soon as one of them requires it.
+func (s *mfaService) IsRequired(ctx context.Context, roles []string) (bool, error) {
+	if len(roles) == 0 {
+		return false, nil
+	}
+	return s.mfaRepository.IsPolicyRequired(ctx, roles)
+}
+
+func (s *mfaService) GetPolicies(ctx context.Context) ([]domain.MFAPolicyResponse, error) {
+	policies, err := s.mfaRepository.GetPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.MFAPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		res = append(res, domain.MFAPolicyResponse{
+			Role:     policy.Role,
+			Required: policy.Required,
+		})
+	}
+	return res, nil
+}
+
+func (s *mfaService) SetPolicy(ctx context.Context, role string, req domain.MFAPolicyRequest) (domain.MFAPolicyResponse, error) {
+	if !slices.Contains(domain.DefaultRoles, role) {
+		return domain.MFAPolicyResponse{}, domain.ErrUnknownRole
+	}
+
+	policy := entities.MFAPolicy{
+		Role:     role,
+		Required: req.Required,
+	}
+	if err := s.mfaRepository.SavePolicy(ctx, &policy); err != nil {
+		return domain.MFAPolicyResponse{}, err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditMFAPolicyUpdated,
+		TargetType: domain.AuditTargetMFAPolicy,
+		TargetID:   role,
+		After:      map[string]any{"required": policy.Required},
+	})
+	return domain.MFAPolicyResponse{
+		Role:     policy.Role,
+		Required: policy.Required,
+	}, nil
+}
+
+func (s *mfaService) getUser(ctx context.Context, userID string) (*entities.User, error) {
+	user, err := s.mfaRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkCode accepts a TOTP code or an unused recovery code, either one only
+// once.
+func (s *mfaService) checkCode(ctx context.Context, user *entities.User, code string) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		step, err := s.matchTOTP(user, code)
+		if err != nil {
+			return err
+		}
+		ok, err := s.mfaRepository.AdvanceStep(ctx, user.ID.String(), step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrMFACodeInvalid
+		}
+		return nil
+	}
+
+	ok, err := s.mfaRepository.UseRecoveryCode(ctx, user.ID.String(), hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// matchTOTP checks the code against the previous, current and next time step
+// and returns the step it matched.
+func (s *mfaService) matchTOTP(user *entities.User, code string) (int64, error) {
+	secret, err := utils.AESDecrypt(user.MFASecret)
+	if err != nil || secret == "" {
+		return 0, domain.ErrMFACodeInvalid
+	}
+
+	now := time.Now()
+	for skew := -1; skew <= 1; skew++ {
+		at := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return at.Unix() / totpPeriod, nil
+		}
+	}
+	return 0, domain.ErrMFACodeInvalid
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes returns codes formatted as XXXX-XXXX-XXXX-XXXX and their
+// hashes for storage.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(b)
+		code := raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
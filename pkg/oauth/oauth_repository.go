@@ -0,0 +1,124 @@
+package oauth
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type (
+	OAuthRepository interface {
+		CreateState(ctx context.Context, state *entities.OAuthState) error
+		ConsumeState(ctx context.Context, stateHash string, provider string) (*entities.OAuthState, error)
+		DeleteExpiredStates(ctx context.Context) error
+		GetIdentity(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error)
+		GetIdentitiesByUser(ctx context.Context, userID string) ([]entities.UserIdentity, error)
+		CreateIdentity(ctx context.Context, identity *entities.UserIdentity) error
+		DeleteIdentity(ctx context.Context, userID string, provider string) (bool, error)
+		ClaimUnverifiedUser(ctx context.Context, userID uuid.UUID) error
+	}
+
+	oauthRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+func (r *oauthRepository) CreateState(ctx context.Context, state *entities.OAuthState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+// ConsumeState deletes and returns the state in one statement so a callback
+// can never be replayed.
+func (r *oauthRepository) ConsumeState(ctx context.Context, stateHash string, provider string) (*entities.OAuthState, error) {
+	var states []entities.OAuthState
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ? AND provider = ? AND expires_at > ?", stateHash, provider, time.Now()).
+		Delete(&states).Error; err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+func (r *oauthRepository) DeleteExpiredStates(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&entities.OAuthState{}).Error
+}
+
+func (r *oauthRepository) GetIdentity(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error) {
+	var identity entities.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		First(&identity, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *oauthRepository) GetIdentitiesByUser(ctx context.Context, userID string) ([]entities.UserIdentity, error) {
+	var identities []entities.UserIdentity
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at asc").
+		Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *oauthRepository) CreateIdentity(ctx context.Context, identity *entities.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *oauthRepository) DeleteIdentity(ctx context.Context, userID string, provider string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Delete(&entities.UserIdentity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ClaimUnverifiedUser hands an unverified account to whoever proved the
+// address through a provider: whoever signed up with it may not own it, so
+// their password, sessions and emailed links stop working in the same
+// transaction the account is verified.
+func (r *oauthRepository) ClaimUnverifiedUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.User{}).
+			Where("id = ? AND verified = ?", userID, false).
+			Updates(map[string]interface{}{"password": "", "verified": true})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// verified in the meantime, it is no longer up for claiming
+			return nil
+		}
+
+		now := time.Now()
+		if err := tx.Model(&entities.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entities.OneTimeToken{}).
+			Where("user_id = ? AND consumed_at IS NULL", userID).
+			Update("consumed_at", now).Error
+	})
+}
@@ -0,0 +1,291 @@
+package oauth
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	provider "Go-Starter-Template/internal/utils/oauth"
+	"Go-Starter-Template/pkg/mfa"
+	"Go-Starter-Template/pkg/user"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"strings"
+	"time"
+)
+
+const stateTTL = 10 * time.Minute
+
+type (
+	OAuthService interface {
+		StartLogin(ctx context.Context, providerName string) (domain.OAuthAuthorizationResponse, error)
+		StartLink(ctx context.Context, providerName string, userID string) (domain.OAuthAuthorizationResponse, error)
+		Callback(ctx context.Context, providerName string, req domain.OAuthCallbackRequest, client domain.SessionClient) (domain.OAuthCallbackResponse, error)
+		GetIdentities(ctx context.Context, userID string) ([]domain.UserIdentityResponse, error)
+		Unlink(ctx context.Context, providerName string, userID string) error
+		PurgeExpiredStates(ctx context.Context) error
+	}
+
+	oauthService struct {
+		oauthRepository OAuthRepository
+		userRepository  user.UserRepository
+		mfaService      mfa.MFAService
+		providers       provider.Providers
+	}
+)
+
+func NewOAuthService(oauthRepository OAuthRepository, userRepository user.UserRepository, mfaService mfa.MFAService, providers provider.Providers) OAuthService {
+	return &oauthService{
+		oauthRepository: oauthRepository,
+		userRepository:  userRepository,
+		mfaService:      mfaService,
+		providers:       providers,
+	}
+}
+
+func (s *oauthService) StartLogin(ctx context.Context, providerName string) (domain.OAuthAuthorizationResponse, error) {
+	return s.authorize(ctx, providerName, nil)
+}
+
+func (s *oauthService) StartLink(ctx context.Context, providerName string, userID string) (domain.OAuthAuthorizationResponse, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return domain.OAuthAuthorizationResponse{}, domain.ErrUserNotFound
+	}
+	return s.authorize(ctx, providerName, &id)
+}
+
+// authorize stores a fresh state, nonce and PKCE verifier and returns the
+// provider URL the client should open.
+func (s *oauthService) authorize(ctx context.Context, providerName string, userID *uuid.UUID) (domain.OAuthAuthorizationResponse, error) {
+	p, err := s.providers.Get(providerName)
+	if err != nil {
+		return domain.OAuthAuthorizationResponse{}, err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return domain.OAuthAuthorizationResponse{}, err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return domain.OAuthAuthorizationResponse{}, err
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	if err := s.oauthRepository.CreateState(ctx, &entities.OAuthState{
+		StateHash:    hashState(state),
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		UserID:       userID,
+		ExpiresAt:    time.Now().Add(stateTTL),
+	}); err != nil {
+		return domain.OAuthAuthorizationResponse{}, err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return domain.OAuthAuthorizationResponse{}, err
+	}
+	return domain.OAuthAuthorizationResponse{AuthorizationURL: authURL}, nil
+}
+
+func (s *oauthService) Callback(ctx context.Context, providerName string, req domain.OAuthCallbackRequest, client domain.SessionClient) (domain.OAuthCallbackResponse, error) {
+	p, err := s.providers.Get(providerName)
+	if err != nil {
+		return domain.OAuthCallbackResponse{}, err
+	}
+
+	state, err := s.oauthRepository.ConsumeState(ctx, hashState(req.State), providerName)
+	if err != nil {
+		return domain.OAuthCallbackResponse{}, err
+	}
+	if state == nil {
+		return domain.OAuthCallbackResponse{}, domain.ErrOAuthStateInvalid
+	}
+
+	identity, err := p.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return domain.OAuthCallbackResponse{}, err
+	}
+
+	if state.UserID != nil {
+		linked, err := s.link(ctx, providerName, identity, *state.UserID)
+		if err != nil {
+			return domain.OAuthCallbackResponse{}, err
+		}
+		return domain.OAuthCallbackResponse{Identity: &linked}, nil
+	}
+
+	u, err := s.findOrCreateUser(ctx, providerName, identity)
+	if err != nil {
+		return domain.OAuthCallbackResponse{}, err
+	}
+
+	if client.DeviceName == "" {
+		client.DeviceName = client.UserAgent
+	}
+	tokens, err := s.mfaService.SignIn(ctx, *u, client, []string{domain.AMRFederated})
+	if err != nil {
+		return domain.OAuthCallbackResponse{}, err
+	}
+	return domain.OAuthCallbackResponse{UserLoginResponse: &tokens}, nil
+}
+
+// findOrCreateUser resolves the identity to a user: an existing link wins,
+// then a user with the same email if the provider verified it, otherwise a
+// new, already verified, user without a password is created. An unverified
+// user with that email is claimed, see ClaimUnverifiedUser.
+func (s *oauthService) findOrCreateUser(ctx context.Context, providerName string, identity provider.Identity) (*entities.User, error) {
+	existing, err := s.oauthRepository.GetIdentity(ctx, providerName, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.User != nil {
+		return existing.User, nil
+	}
+
+	if !identity.EmailVerified || identity.Email == "" {
+		return nil, domain.ErrOAuthEmailNotVerified
+	}
+
+	u, err := s.userRepository.GetEmail(ctx, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		u = &entities.User{
+			ID:             uuid.New(),
+			Name:           identity.Name,
+			Username:       usernameFromEmail(identity.Email),
+			Email:          identity.Email,
+			ProfilePicture: identity.Picture,
+			Role:           domain.RoleUser,
+			Verified:       true,
+		}
+		if err := s.userRepository.CreateUser(ctx, u); err != nil {
+			return nil, domain.ErrRegisterUserFailed
+		}
+	} else if !u.Verified {
+		// the provider proved ownership of the address, the password set by
+		// whoever registered it did not
+		if err := s.oauthRepository.ClaimUnverifiedUser(ctx, u.ID); err != nil {
+			return nil, err
+		}
+		u.Verified = true
+		u.Password = ""
+	}
+
+	if err := s.oauthRepository.CreateIdentity(ctx, &entities.UserIdentity{
+		ID:       uuid.New(),
+		UserID:   u.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *oauthService) link(ctx context.Context, providerName string, identity provider.Identity, userID uuid.UUID) (domain.UserIdentityResponse, error) {
+	existing, err := s.oauthRepository.GetIdentity(ctx, providerName, identity.Subject)
+	if err != nil {
+		return domain.UserIdentityResponse{}, err
+	}
+	if existing != nil {
+		if existing.UserID != userID {
+			return domain.UserIdentityResponse{}, domain.ErrIdentityAlreadyLinked
+		}
+		return toIdentityResponse(*existing), nil
+	}
+
+	linked := entities.UserIdentity{
+		ID:       uuid.New(),
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+	if err := s.oauthRepository.CreateIdentity(ctx, &linked); err != nil {
+		// the user already has another account of this provider linked
+		return domain.UserIdentityResponse{}, domain.ErrIdentityAlreadyLinked
+	}
+	linked.CreatedAt = time.Now()
+	return toIdentityResponse(linked), nil
+}
+
+func (s *oauthService) GetIdentities(ctx context.Context, userID string) ([]domain.UserIdentityResponse, error) {
+	identities, err := s.oauthRepository.GetIdentitiesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.UserIdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		res = append(res, toIdentityResponse(identity))
+	}
+	return res, nil
+}
+
+// Unlink removes a provider, unless it is the last way a user without a
+// password can sign in.
+func (s *oauthService) Unlink(ctx context.Context, providerName string, userID string) error {
+	u, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+
+	if u.Password == "" {
+		identities, err := s.oauthRepository.GetIdentitiesByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return domain.ErrLastLoginMethod
+		}
+	}
+
+	deleted, err := s.oauthRepository.DeleteIdentity(ctx, userID, providerName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return domain.ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (s *oauthService) PurgeExpiredStates(ctx context.Context) error {
+	return s.oauthRepository.DeleteExpiredStates(ctx)
+}
+
+func toIdentityResponse(identity entities.UserIdentity) domain.UserIdentityResponse {
+	return domain.UserIdentityResponse{
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+func usernameFromEmail(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+	return local + "_" + uuid.NewString()[:6]
+}
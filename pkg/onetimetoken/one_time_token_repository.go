@@ -0,0 +1,88 @@
+package onetimetoken
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type (
+	OneTimeTokenRepository interface {
+		CreateToken(ctx context.Context, token *entities.OneTimeToken, revokePrevious bool) error
+		GetLiveToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error)
+		ConsumeToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error)
+		RevokeTokens(ctx context.Context, userID uuid.UUID, purpose string) error
+		DeleteExpiredTokens(ctx context.Context, before time.Time) error
+	}
+
+	oneTimeTokenRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{db: db}
+}
+
+func (r *oneTimeTokenRepository) CreateToken(ctx context.Context, token *entities.OneTimeToken, revokePrevious bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if revokePrevious && token.UserID != nil {
+			if err := tx.Model(&entities.OneTimeToken{}).
+				Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", token.UserID, token.Purpose).
+				Update("consumed_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetLiveToken returns the token if it is unused and unexpired, nil if not.
+func (r *oneTimeTokenRepository) GetLiveToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error) {
+	var tokens []entities.OneTimeToken
+	if err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+		Limit(1).
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return &tokens[0], nil
+}
+
+// ConsumeToken marks a live token consumed and returns it in one statement,
+// two requests racing with the same link cannot both get it.
+func (r *oneTimeTokenRepository) ConsumeToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error) {
+	var tokens []entities.OneTimeToken
+	result := r.db.WithContext(ctx).
+		Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", tokenHash, purpose, time.Now()).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return &tokens[0], nil
+}
+
+func (r *oneTimeTokenRepository) RevokeTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Update("consumed_at", time.Now()).Error
+}
+
+func (r *oneTimeTokenRepository) DeleteExpiredTokens(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Unscoped().
+		Where("expires_at < ?", before).
+		Delete(&entities.OneTimeToken{}).Error
+}
@@ -0,0 +1,118 @@
+package onetimetoken
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// retention keeps used and expired tokens around for a while so support can
+// tell a stale link from a forged one.
+const retention = 7 * 24 * time.Hour
+
+type (
+	OneTimeTokenService interface {
+		Issue(ctx context.Context, req domain.OneTimeTokenRequest) (string, error)
+		Peek(ctx context.Context, purpose string, token string) (*entities.OneTimeToken, error)
+		Consume(ctx context.Context, purpose string, token string) (*entities.OneTimeToken, error)
+		Revoke(ctx context.Context, userID uuid.UUID, purpose string) error
+		PurgeExpiredTokens(ctx context.Context) error
+	}
+
+	oneTimeTokenService struct {
+		oneTimeTokenRepository OneTimeTokenRepository
+	}
+)
+
+func NewOneTimeTokenService(oneTimeTokenRepository OneTimeTokenRepository) OneTimeTokenService {
+	return &oneTimeTokenService{
+		oneTimeTokenRepository: oneTimeTokenRepository,
+	}
+}
+
+// Issue stores a new token and returns its plain value, the only time it is
+// available.
+func (s *oneTimeTokenService) Issue(ctx context.Context, req domain.OneTimeTokenRequest) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	var payload []byte
+	if req.Payload != nil {
+		var err error
+		if payload, err = json.Marshal(req.Payload); err != nil {
+			return "", err
+		}
+	}
+
+	if err := s.oneTimeTokenRepository.CreateToken(ctx, &entities.OneTimeToken{
+		ID:        uuid.New(),
+		TokenHash: hashToken(token),
+		Purpose:   req.Purpose,
+		UserID:    req.UserID,
+		Subject:   req.Subject,
+		Payload:   string(payload),
+		ExpiresAt: time.Now().Add(req.TTL),
+	}, true); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Peek looks a token up like Consume without redeeming it, for checking
+// the rest of a request before the token is spent. Only Consume settles
+// who gets to use it.
+func (s *oneTimeTokenService) Peek(ctx context.Context, purpose string, token string) (*entities.OneTimeToken, error) {
+	if token == "" {
+		return nil, domain.ErrTokenInvalid
+	}
+
+	live, err := s.oneTimeTokenRepository.GetLiveToken(ctx, hashToken(token), purpose)
+	if err != nil {
+		return nil, err
+	}
+	if live == nil {
+		return nil, domain.ErrTokenInvalid
+	}
+	return live, nil
+}
+
+// Consume redeems a token for the given purpose. Unknown, used and expired
+// tokens all return domain.ErrTokenInvalid.
+func (s *oneTimeTokenService) Consume(ctx context.Context, purpose string, token string) (*entities.OneTimeToken, error) {
+	if token == "" {
+		return nil, domain.ErrTokenInvalid
+	}
+
+	consumed, err := s.oneTimeTokenRepository.ConsumeToken(ctx, hashToken(token), purpose)
+	if err != nil {
+		return nil, err
+	}
+	if consumed == nil {
+		return nil, domain.ErrTokenInvalid
+	}
+	return consumed, nil
+}
+
+// Revoke stops every outstanding token of the user for purpose from working.
+func (s *oneTimeTokenService) Revoke(ctx context.Context, userID uuid.UUID, purpose string) error {
+	return s.oneTimeTokenRepository.RevokeTokens(ctx, userID, purpose)
+}
+
+func (s *oneTimeTokenService) PurgeExpiredTokens(ctx context.Context) error {
+	return s.oneTimeTokenRepository.DeleteExpiredTokens(ctx, time.Now().Add(-retention))
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
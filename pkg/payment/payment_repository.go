@@ -0,0 +1,116 @@
+package payment
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type (
+	PaymentRepository interface {
+		CreateTransaction(transaction entities.Transaction) error
+		GetOrderID(ctx context.Context, orderID string) (entities.Transaction, error)
+		SwapStatus(ctx context.Context, transaction entities.Transaction, fromStatus string) (bool, error)
+		GetPendingTransactions(ctx context.Context, createdBefore time.Time) ([]entities.Transaction, error)
+		GetTransactionByID(ctx context.Context, id string) (entities.Transaction, error)
+		CreatePaymentEvent(ctx context.Context, event entities.PaymentEvent) error
+		HasOtherPaidTransaction(ctx context.Context, userID string, exceptID string) (bool, error)
+		SwapRefund(ctx context.Context, id string, fromAmount int64, fromStatus string, toAmount int64, toStatus string) (bool, error)
+	}
+
+	paymentRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db}
+}
+
+func (r *paymentRepository) CreateTransaction(transaction entities.Transaction) error {
+	return r.db.Create(&transaction).Error
+}
+
+func (r *paymentRepository) GetOrderID(ctx context.Context, orderID string) (entities.Transaction, error) {
+	var transaction entities.Transaction
+	if err := r.db.WithContext(ctx).First(&transaction, "order_id = ?", orderID).Error; err != nil {
+		return entities.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// SwapStatus writes the status of transaction, with the paid time, gateway
+// reference and refunded amount that go with it, only if the stored status
+// is still fromStatus. Whoever loses a race against a webhook, a cancel or a
+// refund changes nothing.
+func (r *paymentRepository) SwapStatus(ctx context.Context, transaction entities.Transaction, fromStatus string) (bool, error) {
+	fields := map[string]interface{}{
+		"status":            transaction.Status,
+		"paid_at":           transaction.PaidAt,
+		"gateway_reference": transaction.GatewayReference,
+	}
+	if transaction.Status == domain.TransactionStatusRefunded {
+		fields["refunded_amount"] = transaction.RefundedAmount
+	}
+	result := r.db.WithContext(ctx).
+		Model(&entities.Transaction{}).
+		Where("id = ? AND status = ?", transaction.ID, fromStatus).
+		Updates(fields)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *paymentRepository) GetPendingTransactions(ctx context.Context, createdBefore time.Time) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND created_at < ?", domain.TransactionStatusPending, createdBefore).
+		Order("created_at asc").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *paymentRepository) GetTransactionByID(ctx context.Context, id string) (entities.Transaction, error) {
+	var transaction entities.Transaction
+	if err := r.db.WithContext(ctx).First(&transaction, "id = ?", id).Error; err != nil {
+		return entities.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+func (r *paymentRepository) CreatePaymentEvent(ctx context.Context, event entities.PaymentEvent) error {
+	return r.db.WithContext(ctx).Create(&event).Error
+}
+
+// SwapRefund moves the refunded amount and status of a transaction only if
+// they are still what the caller read, so two refunds can never both spend
+// the same refundable amount.
+func (r *paymentRepository) SwapRefund(ctx context.Context, id string, fromAmount int64, fromStatus string, toAmount int64, toStatus string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.Transaction{}).
+		Where("id = ? AND refunded_amount = ? AND status = ?", id, fromAmount, fromStatus).
+		Updates(map[string]interface{}{"refunded_amount": toAmount, "status": toStatus})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// HasOtherPaidTransaction reports whether the user has a paid transaction,
+// partially refunded ones included, other than exceptID.
+func (r *paymentRepository) HasOtherPaidTransaction(ctx context.Context, userID string, exceptID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&entities.Transaction{}).
+		Where("user_id = ? AND id <> ? AND status IN ?", userID, exceptID,
+			[]string{domain.TransactionStatusPaid, domain.TransactionStatusPartiallyRefunded}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
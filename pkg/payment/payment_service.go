@@ -0,0 +1,490 @@
+package payment
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	gateway "Go-Starter-Template/internal/utils/payment"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/transaction"
+	"Go-Starter-Template/pkg/user"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	PaymentService interface {
+		CreateTransaction(ctx context.Context, req domain.PaymentRequest, userID string) (domain.PaymentInvoiceUrl, error)
+		HandleWebhook(ctx context.Context, gatewayName string, header http.Header, body []byte) (domain.PaymentWebhookResponse, error)
+		ReconcilePendingTransactions(ctx context.Context) error
+		RefundTransaction(ctx context.Context, id string, req domain.RefundTransactionRequest, actorID string) (domain.RefundTransactionResponse, error)
+		CancelTransaction(ctx context.Context, id string, userID string) (domain.CancelTransactionResponse, error)
+	}
+
+	paymentService struct {
+		paymentRepository  PaymentRepository
+		userRepository     user.UserRepository
+		transactionService transaction.TransactionService
+		auditService       audit.AuditService
+		gateways           gateway.Gateways
+		config             gateway.PaymentConfig
+	}
+)
+
+func NewPaymentService(paymentRepository PaymentRepository, userRepository user.UserRepository, transactionService transaction.TransactionService, auditService audit.AuditService, gateways gateway.Gateways) PaymentService {
+	return &paymentService{
+		paymentRepository:  paymentRepository,
+		userRepository:     userRepository,
+		transactionService: transactionService,
+		auditService:       auditService,
+		gateways:           gateways,
+		config:             gateway.LoadPaymentConfig(),
+	}
+}
+
+const (
+	letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numbers = "0123456789"
+
+	subscriptionDescription = "FOODIA Premium Subscription"
+)
+
+func (s *paymentService) CreateTransaction(ctx context.Context, req domain.PaymentRequest, userID string) (domain.PaymentInvoiceUrl, error) {
+	email, err := s.userRepository.GetEmail(ctx, req.Email)
+	if err != nil || email == nil {
+		return domain.PaymentInvoiceUrl{}, domain.ErrEmailNotFound
+	}
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.PaymentInvoiceUrl{}, domain.ErrUserNotFound
+	}
+	if email.Email != user.Email {
+		return domain.PaymentInvoiceUrl{}, domain.ErrUserNotAllowed
+	}
+
+	gatewayName := req.Gateway
+	if gatewayName == "" {
+		gatewayName = s.config.DefaultGateway
+	}
+	paymentGateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return domain.PaymentInvoiceUrl{}, err
+	}
+
+	orderID := GenerateRandomString()
+	checkout, err := paymentGateway.CreateCheckout(ctx, gateway.CheckoutRequest{
+		OrderID:       orderID,
+		Amount:        req.Amount,
+		Description:   subscriptionDescription,
+		CustomerName:  user.Name,
+		CustomerEmail: req.Email,
+		ExpiresIn:     s.config.ExpireAfter,
+	})
+	if err != nil {
+		log.Printf("create %s checkout: %v", gatewayName, err)
+		return domain.PaymentInvoiceUrl{}, domain.ErrCreateTransactionFailed
+	}
+
+	userid, err := uuid.Parse(userID)
+	if err != nil {
+		return domain.PaymentInvoiceUrl{}, domain.ErrParseUUID
+	}
+
+	transact := entities.Transaction{
+		ID:               uuid.New(),
+		UserID:           &userid,
+		Status:           domain.TransactionStatusPending,
+		Invoice:          checkout.RedirectURL,
+		OrderID:          orderID,
+		Amount:           req.Amount,
+		Gateway:          gatewayName,
+		GatewayReference: checkout.Reference,
+	}
+
+	if err = s.paymentRepository.CreateTransaction(transact); err != nil {
+		return domain.PaymentInvoiceUrl{}, err
+	}
+
+	return domain.PaymentInvoiceUrl{
+		Invoice: checkout.RedirectURL,
+		Gateway: gatewayName,
+	}, nil
+}
+
+func (s *paymentService) HandleWebhook(ctx context.Context, gatewayName string, header http.Header, body []byte) (domain.PaymentWebhookResponse, error) {
+	paymentGateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return domain.PaymentWebhookResponse{}, err
+	}
+
+	status, err := paymentGateway.VerifyWebhook(header, body)
+	if err != nil {
+		return domain.PaymentWebhookResponse{}, domain.ErrInvalidSignature
+	}
+
+	transaction, err := s.paymentRepository.GetOrderID(ctx, status.OrderID)
+	if err != nil {
+		return domain.PaymentWebhookResponse{}, domain.ErrTransactionNotFound
+	}
+	if transaction.Gateway != paymentGateway.Name() {
+		return domain.PaymentWebhookResponse{}, domain.ErrTransactionNotFound
+	}
+
+	transaction, err = s.applyTransactionStatus(ctx, transaction, status, domain.PaymentEventSourceWebhook)
+	if err != nil {
+		return domain.PaymentWebhookResponse{}, err
+	}
+
+	return domain.PaymentWebhookResponse{
+		TransactionStatus: transaction.Status,
+		OrderID:           transaction.Invoice,
+	}, nil
+}
+
+// applyTransactionStatus is the state machine shared by the webhooks and the
+// reconciliation job, status has already been normalised by the gateway.
+func (s *paymentService) applyTransactionStatus(ctx context.Context, transaction entities.Transaction, status gateway.PaymentStatus, source string) (entities.Transaction, error) {
+	previousStatus := transaction.Status
+
+	if status.Status != "" && canTransition(previousStatus, status.Status) {
+		transaction.Status = status.Status
+	}
+	if transaction.GatewayReference == "" {
+		transaction.GatewayReference = status.Reference
+	}
+
+	becamePaid := transaction.Status == domain.TransactionStatusPaid && previousStatus != domain.TransactionStatusPaid
+	if becamePaid {
+		now := time.Now()
+		transaction.PaidAt = &now
+	}
+	if transaction.Status == domain.TransactionStatusRefunded {
+		transaction.RefundedAmount = transaction.Amount
+	}
+
+	swapped, err := s.paymentRepository.SwapStatus(ctx, transaction, previousStatus)
+	if err != nil {
+		return entities.Transaction{}, err
+	}
+	if !swapped {
+		// changed since it was read, by a retried notification, a cancel or
+		// a refund, which has already done what follows
+		return s.paymentRepository.GetTransactionByID(ctx, transaction.ID.String())
+	}
+
+	if transaction.Status == previousStatus {
+		return transaction, nil
+	}
+
+	s.recordEvent(ctx, transaction, entities.PaymentEvent{
+		Source:     source,
+		Type:       domain.PaymentEventStatusChanged,
+		FromStatus: previousStatus,
+		Note:       status.RawStatus,
+	})
+
+	if err := s.adjustSubscription(ctx, transaction); err != nil {
+		return entities.Transaction{}, err
+	}
+
+	if becamePaid {
+		gateway.LogTransaction(transaction)
+		// the webhook must answer quickly, the receipt is mailed in the background
+		go func(id string) {
+			if err := s.transactionService.IssueReceipt(context.Background(), id); err != nil {
+				log.Printf("issue receipt for transaction %s: %v", id, err)
+			}
+		}(transaction.ID.String())
+	}
+
+	return transaction, nil
+}
+
+// RefundTransaction refunds a paid transaction through its gateway, a zero
+// amount refunds whatever has not been refunded yet.
+func (s *paymentService) RefundTransaction(ctx context.Context, id string, req domain.RefundTransactionRequest, actorID string) (domain.RefundTransactionResponse, error) {
+	transaction, err := s.getTransaction(ctx, id)
+	if err != nil {
+		return domain.RefundTransactionResponse{}, err
+	}
+
+	if transaction.Status != domain.TransactionStatusPaid && transaction.Status != domain.TransactionStatusPartiallyRefunded {
+		return domain.RefundTransactionResponse{}, domain.ErrTransactionNotRefundable
+	}
+
+	refundable := transaction.Amount - transaction.RefundedAmount
+	amount := req.Amount
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		return domain.RefundTransactionResponse{}, domain.ErrRefundAmountExceeded
+	}
+
+	paymentGateway, err := s.gateways.Get(transaction.Gateway)
+	if err != nil {
+		return domain.RefundTransactionResponse{}, err
+	}
+
+	// reserve the amount before the gateway is asked for it, a concurrent
+	// refund sees the new refunded amount or loses the swap
+	previousStatus, previousRefunded := transaction.Status, transaction.RefundedAmount
+	transaction.RefundedAmount += amount
+	transaction.Status = domain.TransactionStatusPartiallyRefunded
+	if transaction.RefundedAmount >= transaction.Amount {
+		transaction.Status = domain.TransactionStatusRefunded
+	}
+	reserved, err := s.paymentRepository.SwapRefund(ctx, transaction.ID.String(), previousRefunded, previousStatus, transaction.RefundedAmount, transaction.Status)
+	if err != nil {
+		return domain.RefundTransactionResponse{}, err
+	}
+	if !reserved {
+		return domain.RefundTransactionResponse{}, domain.ErrRefundConflict
+	}
+
+	// the key follows from the reservation, retrying the same refund after
+	// a timeout reaches the gateway as the same refund
+	result, err := paymentGateway.Refund(ctx, gateway.RefundRequest{
+		OrderID:   transaction.OrderID,
+		Reference: transaction.GatewayReference,
+		RefundKey: fmt.Sprintf("%s-refund-%d", transaction.OrderID, transaction.RefundedAmount),
+		Amount:    amount,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		log.Printf("refund order %s: %v", transaction.OrderID, err)
+		if _, err := s.paymentRepository.SwapRefund(ctx, transaction.ID.String(), transaction.RefundedAmount, transaction.Status, previousRefunded, previousStatus); err != nil {
+			log.Printf("release refund of order %s: %v", transaction.OrderID, err)
+		}
+		return domain.RefundTransactionResponse{}, domain.ErrRefundFailed
+	}
+
+	s.recordEvent(ctx, transaction, entities.PaymentEvent{
+		ActorID:    parseActor(actorID),
+		Source:     domain.PaymentEventSourceAdmin,
+		Type:       domain.PaymentEventRefunded,
+		FromStatus: previousStatus,
+		Amount:     amount,
+		Reference:  result.RefundID,
+		Note:       req.Reason,
+	})
+
+	if err := s.adjustSubscription(ctx, transaction); err != nil {
+		return domain.RefundTransactionResponse{}, err
+	}
+
+	return domain.RefundTransactionResponse{
+		TransactionID:  transaction.ID.String(),
+		Status:         transaction.Status,
+		RefundedAmount: transaction.RefundedAmount,
+		RefundID:       result.RefundID,
+	}, nil
+}
+
+func (s *paymentService) CancelTransaction(ctx context.Context, id string, userID string) (domain.CancelTransactionResponse, error) {
+	transaction, err := s.getTransaction(ctx, id)
+	if err != nil {
+		return domain.CancelTransactionResponse{}, err
+	}
+	if transaction.UserID == nil || transaction.UserID.String() != userID {
+		return domain.CancelTransactionResponse{}, domain.ErrTransactionNotFound
+	}
+	if transaction.Status != domain.TransactionStatusPending {
+		return domain.CancelTransactionResponse{}, domain.ErrTransactionNotPending
+	}
+
+	paymentGateway, err := s.gateways.Get(transaction.Gateway)
+	if err != nil {
+		return domain.CancelTransactionResponse{}, err
+	}
+	if err := paymentGateway.Cancel(ctx, transaction.OrderID, transaction.GatewayReference); err != nil {
+		log.Printf("cancel order %s: %v", transaction.OrderID, err)
+		return domain.CancelTransactionResponse{}, domain.ErrCancelFailed
+	}
+
+	previousStatus := transaction.Status
+	transaction.Status = domain.TransactionStatusCancelled
+	swapped, err := s.paymentRepository.SwapStatus(ctx, transaction, previousStatus)
+	if err != nil {
+		return domain.CancelTransactionResponse{}, err
+	}
+	if !swapped {
+		// a settlement got in first
+		return domain.CancelTransactionResponse{}, domain.ErrTransactionNotPending
+	}
+
+	s.recordEvent(ctx, transaction, entities.PaymentEvent{
+		ActorID:    parseActor(userID),
+		Source:     domain.PaymentEventSourceUser,
+		Type:       domain.PaymentEventCancelled,
+		FromStatus: previousStatus,
+	})
+
+	if err := s.adjustSubscription(ctx, transaction); err != nil {
+		return domain.CancelTransactionResponse{}, err
+	}
+
+	return domain.CancelTransactionResponse{
+		TransactionID: transaction.ID.String(),
+		Status:        transaction.Status,
+	}, nil
+}
+
+func (s *paymentService) getTransaction(ctx context.Context, id string) (entities.Transaction, error) {
+	transaction, err := s.paymentRepository.GetTransactionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Transaction{}, domain.ErrTransactionNotFound
+		}
+		return entities.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// adjustSubscription grants the subscription once a transaction is paid and
+// takes it back when the payment is fully refunded.
+func (s *paymentService) adjustSubscription(ctx context.Context, transaction entities.Transaction) error {
+	if transaction.UserID == nil {
+		return nil
+	}
+	switch transaction.Status {
+	case domain.TransactionStatusPaid:
+		return s.userRepository.UpdateSubscriptionStatus(ctx, transaction.UserID.String(), true)
+	case domain.TransactionStatusRefunded:
+		// another payment still pays for the subscription
+		paid, err := s.paymentRepository.HasOtherPaidTransaction(ctx, transaction.UserID.String(), transaction.ID.String())
+		if err != nil || paid {
+			return err
+		}
+		return s.userRepository.UpdateSubscriptionStatus(ctx, transaction.UserID.String(), false)
+	}
+	return nil
+}
+
+// recordEvent never fails the caller, the money has already moved by now.
+func (s *paymentService) recordEvent(ctx context.Context, transaction entities.Transaction, event entities.PaymentEvent) {
+	event.ID = uuid.New()
+	event.TransactionID = transaction.ID
+	event.ToStatus = transaction.Status
+	if err := s.paymentRepository.CreatePaymentEvent(ctx, event); err != nil {
+		log.Printf("record payment event for transaction %s: %v", transaction.ID, err)
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditTransactionPrefix + event.Type,
+		TargetType: domain.AuditTargetTransaction,
+		TargetID:   transaction.ID.String(),
+		Before:     map[string]any{"status": event.FromStatus},
+		After: map[string]any{
+			"status":          event.ToStatus,
+			"source":          event.Source,
+			"amount":          event.Amount,
+			"refunded_amount": transaction.RefundedAmount,
+			"reference":       event.Reference,
+		},
+	})
+}
+
+// canTransition rejects late or replayed notifications, e.g. a settlement
+// arriving after the transaction has been refunded. A cancelled transaction
+// can still be paid: an order cancelled before its snap page was opened was
+// never expired on the gateway, and money that arrives is recorded.
+func canTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	switch from {
+	case domain.TransactionStatusPending:
+		return true
+	case domain.TransactionStatusCancelled:
+		return to == domain.TransactionStatusPaid
+	case domain.TransactionStatusPaid:
+		return to == domain.TransactionStatusRefunded || to == domain.TransactionStatusPartiallyRefunded
+	case domain.TransactionStatusPartiallyRefunded:
+		return to == domain.TransactionStatusRefunded
+	}
+	return false
+}
+
+func parseActor(id string) *uuid.UUID {
+	actorID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &actorID
+}
+
+// ReconcilePendingTransactions picks up transactions whose webhook never
+// arrived, asks their gateway for the status and expires the stale ones.
+func (s *paymentService) ReconcilePendingTransactions(ctx context.Context) error {
+	now := time.Now()
+	transactions, err := s.paymentRepository.GetPendingTransactions(ctx, now.Add(-s.config.PendingAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		stale := transaction.CreatedAt.Before(now.Add(-s.config.ExpireAfter))
+
+		paymentGateway, err := s.gateways.Get(transaction.Gateway)
+		if err != nil {
+			log.Printf("reconcile order %s: %v", transaction.OrderID, err)
+			continue
+		}
+
+		status, err := paymentGateway.QueryStatus(ctx, transaction.OrderID, transaction.GatewayReference)
+		switch {
+		case errors.Is(err, gateway.ErrGatewayPaymentNotFound):
+			// the user never opened the payment page, nothing to ask the gateway about
+			if !stale {
+				continue
+			}
+			status = gateway.PaymentStatus{Status: domain.TransactionStatusFailed}
+		case err != nil:
+			log.Printf("reconcile order %s: %v", transaction.OrderID, err)
+			continue
+		case status.Status == domain.TransactionStatusPending && stale:
+			status.Status = domain.TransactionStatusFailed
+		}
+
+		// the checkout stays payable on the gateway until it is expired there
+		// too, failing it only here would drop a late settlement
+		if status.Status == domain.TransactionStatusFailed {
+			if err := paymentGateway.Cancel(ctx, transaction.OrderID, transaction.GatewayReference); err != nil && !errors.Is(err, gateway.ErrGatewayPaymentNotFound) {
+				log.Printf("reconcile order %s: cancel: %v", transaction.OrderID, err)
+				continue
+			}
+		}
+
+		if _, err := s.applyTransactionStatus(ctx, transaction, status, domain.PaymentEventSourceReconcile); err != nil {
+			log.Printf("reconcile order %s: %v", transaction.OrderID, err)
+		}
+	}
+
+	return nil
+}
+
+func GenerateRandomString() string {
+	result := make([]byte, 8)
+
+	for i := 0; i < 4; i++ {
+		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		result[i] = letters[num.Int64()]
+	}
+
+	for i := 4; i < 8; i++ {
+		num, _ := rand.Int(rand.Reader, big.NewInt(int64(len(numbers))))
+		result[i] = numbers[num.Int64()]
+	}
+
+	return string(result)
+}
@@ -0,0 +1,99 @@
+package rbac
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"gorm.io/gorm"
+)
+
+type (
+	RBACRepository interface {
+		GetUserByID(ctx context.Context, userID string) (*entities.User, error)
+		GetPermissionNames(ctx context.Context, userID string, implicitRoles []string) ([]string, error)
+		GetRoles(ctx context.Context) ([]entities.Role, error)
+		GetRoleByName(ctx context.Context, name string) (*entities.Role, error)
+		GetPermissions(ctx context.Context) ([]entities.Permission, error)
+		GetPermissionsByName(ctx context.Context, names []string) ([]entities.Permission, error)
+		ReplaceRolePermissions(ctx context.Context, role *entities.Role, permissions []entities.Permission) error
+		AssignRole(ctx context.Context, user *entities.User, role *entities.Role) error
+		RemoveRole(ctx context.Context, user *entities.User, role *entities.Role) error
+	}
+
+	rbacRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRBACRepository(db *gorm.DB) RBACRepository {
+	return &rbacRepository{db: db}
+}
+
+func (r *rbacRepository) GetUserByID(ctx context.Context, userID string) (*entities.User, error) {
+	var user entities.User
+	if err := r.db.WithContext(ctx).Preload("Roles").First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetPermissionNames returns every permission granted through the roles
+// assigned to the user or named in implicitRoles.
+func (r *rbacRepository) GetPermissionNames(ctx context.Context, userID string, implicitRoles []string) ([]string, error) {
+	var names []string
+	if err := r.db.WithContext(ctx).
+		Table("permissions").
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.deleted_at IS NULL AND permissions.deleted_at IS NULL").
+		Where(r.db.Where("roles.name IN ?", implicitRoles).
+			Or("roles.id IN (?)", r.db.Table("user_roles").Select("role_id").Where("user_id = ?", userID))).
+		Pluck("permissions.name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (r *rbacRepository) GetRoles(ctx context.Context) ([]entities.Role, error) {
+	var roles []entities.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *rbacRepository) GetRoleByName(ctx context.Context, name string) (*entities.Role, error) {
+	var role entities.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").First(&role, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *rbacRepository) GetPermissions(ctx context.Context) ([]entities.Permission, error) {
+	var permissions []entities.Permission
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *rbacRepository) GetPermissionsByName(ctx context.Context, names []string) ([]entities.Permission, error) {
+	var permissions []entities.Permission
+	if err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *rbacRepository) ReplaceRolePermissions(ctx context.Context, role *entities.Role, permissions []entities.Permission) error {
+	return r.db.WithContext(ctx).Model(role).Association("Permissions").Replace(permissions)
+}
+
+func (r *rbacRepository) AssignRole(ctx context.Context, user *entities.User, role *entities.Role) error {
+	return r.db.WithContext(ctx).Model(user).Association("Roles").Append(role)
+}
+
+func (r *rbacRepository) RemoveRole(ctx context.Context, user *entities.User, role *entities.Role) error {
+	return r.db.WithContext(ctx).Model(user).Association("Roles").Delete(role)
+}
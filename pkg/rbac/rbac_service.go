@@ -0,0 +1,348 @@
+package rbac
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/pkg/audit"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	RBACService interface {
+		HasPermission(ctx context.Context, userID string, permission string) (bool, error)
+		GetEffectiveRoles(ctx context.Context, userID string) ([]string, error)
+		GetUserRoles(ctx context.Context, userID string) (domain.UserRolesResponse, error)
+		AssignRole(ctx context.Context, userID string, roleName string) (domain.UserRolesResponse, error)
+		RemoveRole(ctx context.Context, userID string, roleName string) (domain.UserRolesResponse, error)
+		GetRoles(ctx context.Context) ([]domain.RoleResponse, error)
+		GetPermissions(ctx context.Context) ([]domain.PermissionResponse, error)
+		UpdateRolePermissions(ctx context.Context, roleName string, req domain.UpdateRolePermissionsRequest) (domain.RoleResponse, error)
+	}
+
+	rbacService struct {
+		rbacRepository RBACRepository
+		auditService   audit.AuditService
+		cacheTTL       time.Duration
+
+		mu    sync.Mutex
+		cache map[string]cachedPermissions
+		// generation moves on every invalidation, a fill that started
+		// before one is not stored
+		generation uint64
+		nextSweep  time.Time
+	}
+
+	// cachedPermissions spares a join on every request. Changes made through
+	// this service drop the entry right away, anything else (a subscription
+	// ending, another instance) is picked up once it expires. Expired entries
+	// are swept out once per TTL.
+	cachedPermissions struct {
+		roles       []string
+		permissions []string
+		expiresAt   time.Time
+	}
+)
+
+func NewRBACService(rbacRepository RBACRepository, auditService audit.AuditService) RBACService {
+	return &rbacService{
+		rbacRepository: rbacRepository,
+		auditService:   auditService,
+		cacheTTL:       utils.GetDurationConfig("RBAC_CACHE_TTL", time.Minute),
+		cache:          make(map[string]cachedPermissions),
+	}
+}
+
+func (s *rbacService) HasPermission(ctx context.Context, userID string, permission string) (bool, error) {
+	access, err := s.userAccess(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, granted := range access.permissions {
+		if matchPermission(granted, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetEffectiveRoles returns the names of the roles assigned to the user and
+// of those the user holds implicitly.
+func (s *rbacService) GetEffectiveRoles(ctx context.Context, userID string) ([]string, error) {
+	access, err := s.userAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return access.roles, nil
+}
+
+func (s *rbacService) GetUserRoles(ctx context.Context, userID string) (domain.UserRolesResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	return s.toUserRolesResponse(ctx, user)
+}
+
+func (s *rbacService) AssignRole(ctx context.Context, userID string, roleName string) (domain.UserRolesResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+
+	if err := s.rbacRepository.AssignRole(ctx, user, role); err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	s.invalidate(userID)
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserRoleAssigned,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		After:      map[string]any{"role": roleName},
+	})
+
+	return s.GetUserRoles(ctx, userID)
+}
+
+func (s *rbacService) RemoveRole(ctx context.Context, userID string, roleName string) (domain.UserRolesResponse, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	if slices.Contains(implicitRoles(user), roleName) && !hasRole(user.Roles, roleName) {
+		return domain.UserRolesResponse{}, domain.ErrImplicitRole
+	}
+
+	if err := s.rbacRepository.RemoveRole(ctx, user, role); err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	s.invalidate(userID)
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserRoleRemoved,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]any{"role": roleName},
+	})
+
+	return s.GetUserRoles(ctx, userID)
+}
+
+func (s *rbacService) GetRoles(ctx context.Context) ([]domain.RoleResponse, error) {
+	roles, err := s.rbacRepository.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, toRoleResponse(role))
+	}
+	return res, nil
+}
+
+func (s *rbacService) GetPermissions(ctx context.Context) ([]domain.PermissionResponse, error) {
+	permissions, err := s.rbacRepository.GetPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		res = append(res, domain.PermissionResponse{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+	return res, nil
+}
+
+func (s *rbacService) UpdateRolePermissions(ctx context.Context, roleName string, req domain.UpdateRolePermissionsRequest) (domain.RoleResponse, error) {
+	role, err := s.getRole(ctx, roleName)
+	if err != nil {
+		return domain.RoleResponse{}, err
+	}
+
+	permissions, err := s.rbacRepository.GetPermissionsByName(ctx, req.Permissions)
+	if err != nil {
+		return domain.RoleResponse{}, err
+	}
+	for _, name := range req.Permissions {
+		if !slices.ContainsFunc(permissions, func(p entities.Permission) bool { return p.Name == name }) {
+			return domain.RoleResponse{}, domain.ErrPermissionNotFound
+		}
+	}
+
+	before := toRoleResponse(*role)
+	if err := s.rbacRepository.ReplaceRolePermissions(ctx, role, permissions); err != nil {
+		return domain.RoleResponse{}, err
+	}
+	// any number of users may hold the role
+	s.invalidateAll()
+
+	role.Permissions = permissions
+	after := toRoleResponse(*role)
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditRolePermissionsUpdated,
+		TargetType: domain.AuditTargetRole,
+		TargetID:   roleName,
+		Before:     before,
+		After:      after,
+	})
+	return after, nil
+}
+
+func (s *rbacService) userAccess(ctx context.Context, userID string) (cachedPermissions, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	generation := s.generation
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return cachedPermissions{}, err
+	}
+	permissions, err := s.rbacRepository.GetPermissionNames(ctx, userID, implicitRoles(user))
+	if err != nil {
+		return cachedPermissions{}, err
+	}
+	roles := implicitRoles(user)
+	for _, role := range user.Roles {
+		if !slices.Contains(roles, role.Name) {
+			roles = append(roles, role.Name)
+		}
+	}
+
+	now := time.Now()
+	cached = cachedPermissions{
+		roles:       roles,
+		permissions: permissions,
+		expiresAt:   now.Add(s.cacheTTL),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.After(s.nextSweep) {
+		for id, entry := range s.cache {
+			if now.After(entry.expiresAt) {
+				delete(s.cache, id)
+			}
+		}
+		s.nextSweep = now.Add(s.cacheTTL)
+	}
+	// an invalidation ran while this was read, the result may be stale
+	if s.generation == generation {
+		s.cache[userID] = cached
+	}
+	return cached, nil
+}
+
+func (s *rbacService) invalidate(userID string) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *rbacService) invalidateAll() {
+	s.mu.Lock()
+	s.cache = make(map[string]cachedPermissions)
+	s.generation++
+	s.mu.Unlock()
+}
+
+func (s *rbacService) toUserRolesResponse(ctx context.Context, user *entities.User) (domain.UserRolesResponse, error) {
+	permissions, err := s.rbacRepository.GetPermissionNames(ctx, user.ID.String(), implicitRoles(user))
+	if err != nil {
+		return domain.UserRolesResponse{}, err
+	}
+	slices.Sort(permissions)
+
+	assigned := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		assigned = append(assigned, role.Name)
+	}
+
+	return domain.UserRolesResponse{
+		Assigned:    assigned,
+		Implicit:    implicitRoles(user),
+		Permissions: permissions,
+	}, nil
+}
+
+func (s *rbacService) getUser(ctx context.Context, userID string) (*entities.User, error) {
+	user, err := s.rbacRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *rbacService) getRole(ctx context.Context, name string) (*entities.Role, error) {
+	role, err := s.rbacRepository.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// implicitRoles are held because of the account itself rather than an
+// assignment: the legacy User.Role and premium while subscribed.
+func implicitRoles(user *entities.User) []string {
+	roles := []string{user.Role}
+	if user.Subscribe && user.Role != domain.RolePremium {
+		roles = append(roles, domain.RolePremium)
+	}
+	return roles
+}
+
+func hasRole(roles []entities.Role, name string) bool {
+	return slices.ContainsFunc(roles, func(r entities.Role) bool { return r.Name == name })
+}
+
+// matchPermission reports whether granted covers required, either exactly or
+// through a trailing "*" segment.
+func matchPermission(granted, required string) bool {
+	if granted == required || granted == domain.PermAll {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, "*")
+	return ok && strings.HasPrefix(required, prefix)
+}
+
+func toRoleResponse(role entities.Role) domain.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	slices.Sort(permissions)
+
+	return domain.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"Go-Starter-Template/entities"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type (
+	SessionRepository interface {
+		CreateSession(ctx context.Context, session *entities.Session) error
+		GetSessionByID(ctx context.Context, id string) (*entities.Session, error)
+		RotateRefreshToken(ctx context.Context, id string, oldHash string, newHash string, expiresAt time.Time) (bool, error)
+		TouchSession(ctx context.Context, id string, lastSeenAt time.Time) error
+		RevokeSession(ctx context.Context, id string, userID string) (bool, error)
+		RevokeAllSessions(ctx context.Context, userID string, exceptID string) error
+		GetActiveSessionsByUser(ctx context.Context, userID string) ([]entities.Session, error)
+	}
+
+	sessionRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *entities.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*entities.Session, error) {
+	var session entities.Session
+	if err := r.db.WithContext(ctx).Preload("User").First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateRefreshToken swaps the stored refresh token hash only when it still
+// matches oldHash, so two concurrent refreshes cannot both succeed.
+func (r *sessionRepository) RotateRefreshToken(ctx context.Context, id string, oldHash string, newHash string, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, oldHash).
+		Updates(map[string]any{
+			"refresh_token_hash": newHash,
+			"previous_hash":      oldHash,
+			"expires_at":         expiresAt,
+			"last_seen_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *sessionRepository) TouchSession(ctx context.Context, id string, lastSeenAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&entities.Session{}).
+		Where("id = ?", id).
+		Update("last_seen_at", lastSeenAt).Error
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, id string, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entities.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *sessionRepository) RevokeAllSessions(ctx context.Context, userID string, exceptID string) error {
+	query := r.db.WithContext(ctx).
+		Model(&entities.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	return query.Update("revoked_at", time.Now()).Error
+}
+
+func (r *sessionRepository) GetActiveSessionsByUser(ctx context.Context, userID string) ([]entities.Session, error) {
+	var sessions []entities.Session
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/pkg/jwt"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// lastSeenResolution limits how often an authenticated request writes the
+// session's last seen time.
+const lastSeenResolution = time.Minute
+
+type (
+	SessionService interface {
+		CreateSession(ctx context.Context, user entities.User, client domain.SessionClient, amr []string) (domain.UserLoginResponse, error)
+		Refresh(ctx context.Context, refreshToken string, client domain.SessionClient) (domain.UserLoginResponse, error)
+		ValidateSession(ctx context.Context, sessionID string, userID string) error
+		GetSessions(ctx context.Context, userID string, currentSessionID string) ([]domain.SessionResponse, error)
+		RevokeSession(ctx context.Context, sessionID string, userID string) error
+		RevokeAllSessions(ctx context.Context, userID string, exceptSessionID string) error
+	}
+
+	sessionService struct {
+		sessionRepository SessionRepository
+		jwtService        jwt.JWTService
+		refreshTokenTTL   time.Duration
+	}
+)
+
+func NewSessionService(sessionRepository SessionRepository, jwtService jwt.JWTService) SessionService {
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		jwtService:        jwtService,
+		refreshTokenTTL:   utils.GetDurationConfig("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	}
+}
+
+// CreateSession starts a session for a user who completed every required
+// factor, amr lists the methods used and is carried over on refresh.
+func (s *sessionService) CreateSession(ctx context.Context, user entities.User, client domain.SessionClient, amr []string) (domain.UserLoginResponse, error) {
+	if user.SuspendedAt != nil {
+		return domain.UserLoginResponse{}, domain.ErrAccountSuspended
+	}
+
+	now := time.Now()
+	session := entities.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		DeviceName: client.DeviceName,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+		AMR:        strings.Join(amr, ","),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTokenTTL),
+	}
+
+	refreshToken, refreshTokenHash, err := newRefreshToken(session.ID.String())
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	session.RefreshTokenHash = refreshTokenHash
+
+	if err := s.sessionRepository.CreateSession(ctx, &session); err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+
+	return domain.UserLoginResponse{
+		Token:        s.jwtService.GenerateTokenUser(user.ID.String(), user.Role, session.ID.String(), amr),
+		RefreshToken: refreshToken,
+		Role:         user.Role,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Presenting the refresh token that was just rotated means it leaked, so the
+// whole session is revoked. Any other wrong token is only rejected, knowing a
+// session id must not be enough to sign it out.
+func (s *sessionService) Refresh(ctx context.Context, refreshToken string, client domain.SessionClient) (domain.UserLoginResponse, error) {
+	sessionID, _, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return domain.UserLoginResponse{}, domain.ErrRefreshTokenInvalid
+	}
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return domain.UserLoginResponse{}, domain.ErrRefreshTokenInvalid
+	}
+
+	session, err := s.sessionRepository.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.UserLoginResponse{}, domain.ErrRefreshTokenInvalid
+		}
+		return domain.UserLoginResponse{}, err
+	}
+	if err := checkSession(session); err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+
+	presentedHash := hashRefreshToken(refreshToken)
+	if presentedHash != session.RefreshTokenHash {
+		if session.PreviousHash != "" && presentedHash == session.PreviousHash {
+			if _, err := s.sessionRepository.RevokeSession(ctx, sessionID, session.UserID.String()); err != nil {
+				return domain.UserLoginResponse{}, err
+			}
+		}
+		return domain.UserLoginResponse{}, domain.ErrRefreshTokenInvalid
+	}
+
+	newToken, newHash, err := newRefreshToken(sessionID)
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	rotated, err := s.sessionRepository.RotateRefreshToken(ctx, sessionID, presentedHash, newHash, time.Now().Add(s.refreshTokenTTL))
+	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	if !rotated {
+		return domain.UserLoginResponse{}, domain.ErrRefreshTokenInvalid
+	}
+
+	role := domain.RoleUser
+	if session.User != nil {
+		role = session.User.Role
+	}
+
+	return domain.UserLoginResponse{
+		Token:        s.jwtService.GenerateTokenUser(session.UserID.String(), role, sessionID, splitAMR(session.AMR)),
+		RefreshToken: newToken,
+		Role:         role,
+	}, nil
+}
+
+func (s *sessionService) ValidateSession(ctx context.Context, sessionID string, userID string) error {
+	session, err := s.sessionRepository.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID.String() != userID {
+		return domain.ErrSessionNotFound
+	}
+	if err := checkSession(session); err != nil {
+		return err
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > lastSeenResolution {
+		return s.sessionRepository.TouchSession(ctx, sessionID, now)
+	}
+	return nil
+}
+
+func (s *sessionService) GetSessions(ctx context.Context, userID string, currentSessionID string) ([]domain.SessionResponse, error) {
+	sessions, err := s.sessionRepository.GetActiveSessionsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, domain.SessionResponse{
+			ID:         session.ID.String(),
+			DeviceName: session.DeviceName,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+			Current:    session.ID.String() == currentSessionID,
+		})
+	}
+	return res, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, sessionID string, userID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return domain.ErrSessionNotFound
+	}
+	revoked, err := s.sessionRepository.RevokeSession(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userID string, exceptSessionID string) error {
+	return s.sessionRepository.RevokeAllSessions(ctx, userID, exceptSessionID)
+}
+
+func splitAMR(amr string) []string {
+	if amr == "" {
+		return nil
+	}
+	return strings.Split(amr, ",")
+}
+
+func checkSession(session *entities.Session) error {
+	if session.RevokedAt != nil {
+		return domain.ErrSessionRevoked
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return domain.ErrSessionExpired
+	}
+	if session.User != nil && session.User.SuspendedAt != nil {
+		return domain.ErrAccountSuspended
+	}
+	return nil
+}
+
+// newRefreshToken returns an opaque "<session id>.<secret>" token and the hash
+// that is stored in place of it.
+func newRefreshToken(sessionID string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token := sessionID + "." + base64.RawURLEncoding.EncodeToString(secret)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
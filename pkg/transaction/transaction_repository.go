@@ -0,0 +1,50 @@
+package transaction
+
+import (
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/pkg/utility"
+	"context"
+	"gorm.io/gorm"
+)
+
+type (
+	TransactionRepository interface {
+		GetTransactionsByUser(ctx context.Context, userID string, meta *pagination.Meta) ([]entities.Transaction, error)
+		GetTransactionByID(ctx context.Context, id string) (*entities.Transaction, error)
+		UpdateReceiptURL(ctx context.Context, id string, receiptURL string) error
+	}
+
+	transactionRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
+	return &transactionRepository{db: db}
+}
+
+func (r *transactionRepository) GetTransactionsByUser(ctx context.Context, userID string, meta *pagination.Meta) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+
+	query := r.db.WithContext(ctx).Model(&entities.Transaction{}).Where("user_id = ?", userID)
+	query = utility.WithFilters(query, meta, utility.AddModels(entities.Transaction{}, "transactions"))
+	if err := query.Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (r *transactionRepository) GetTransactionByID(ctx context.Context, id string) (*entities.Transaction, error) {
+	var transaction entities.Transaction
+	if err := r.db.WithContext(ctx).Preload("User").First(&transaction, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r *transactionRepository) UpdateReceiptURL(ctx context.Context, id string, receiptURL string) error {
+	return r.db.WithContext(ctx).Model(&entities.Transaction{}).
+		Where("id = ?", id).
+		Update("receipt_url", receiptURL).Error
+}
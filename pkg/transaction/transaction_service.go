@@ -0,0 +1,206 @@
+package transaction
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/entities"
+	"Go-Starter-Template/internal/utils/invoice"
+	"Go-Starter-Template/internal/utils/mailing"
+	"Go-Starter-Template/internal/utils/pagination"
+	"Go-Starter-Template/internal/utils/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"html/template"
+	"os"
+)
+
+const subscriptionDescription = "FOODIA Premium Subscription"
+
+type (
+	TransactionService interface {
+		GetTransactions(ctx context.Context, userID string, meta pagination.Meta) (domain.TransactionListResponse, error)
+		GetTransactionDetail(ctx context.Context, id string, userID string) (domain.TransactionResponse, error)
+		GetInvoice(ctx context.Context, id string, userID string) (domain.TransactionDocument, error)
+		GetReceipt(ctx context.Context, id string, userID string) (domain.TransactionDocument, error)
+		IssueReceipt(ctx context.Context, id string) error
+	}
+
+	transactionService struct {
+		transactionRepository TransactionRepository
+		blobStore             storage.BlobStore
+	}
+)
+
+func NewTransactionService(transactionRepository TransactionRepository, blobStore storage.BlobStore) TransactionService {
+	return &transactionService{
+		transactionRepository: transactionRepository,
+		blobStore:             blobStore,
+	}
+}
+
+func (s *transactionService) GetTransactions(ctx context.Context, userID string, meta pagination.Meta) (domain.TransactionListResponse, error) {
+	transactions, err := s.transactionRepository.GetTransactionsByUser(ctx, userID, &meta)
+	if err != nil {
+		return domain.TransactionListResponse{}, err
+	}
+
+	items := make([]domain.TransactionResponse, 0, len(transactions))
+	for _, transaction := range transactions {
+		items = append(items, s.toTransactionResponse(transaction))
+	}
+
+	return domain.TransactionListResponse{
+		Items: items,
+		Meta:  meta,
+	}, nil
+}
+
+func (s *transactionService) GetTransactionDetail(ctx context.Context, id string, userID string) (domain.TransactionResponse, error) {
+	transaction, err := s.getOwnedTransaction(ctx, id, userID)
+	if err != nil {
+		return domain.TransactionResponse{}, err
+	}
+	return s.toTransactionResponse(*transaction), nil
+}
+
+func (s *transactionService) GetInvoice(ctx context.Context, id string, userID string) (domain.TransactionDocument, error) {
+	transaction, err := s.getOwnedTransaction(ctx, id, userID)
+	if err != nil {
+		return domain.TransactionDocument{}, err
+	}
+
+	content, err := invoice.Render(buildDocument(*transaction, invoice.KindInvoice))
+	if err != nil {
+		return domain.TransactionDocument{}, err
+	}
+
+	return domain.TransactionDocument{
+		Filename: fmt.Sprintf("invoice-%s.pdf", transaction.OrderID),
+		Content:  content,
+	}, nil
+}
+
+func (s *transactionService) GetReceipt(ctx context.Context, id string, userID string) (domain.TransactionDocument, error) {
+	transaction, err := s.getOwnedTransaction(ctx, id, userID)
+	if err != nil {
+		return domain.TransactionDocument{}, err
+	}
+	if transaction.Status != domain.TransactionStatusPaid {
+		return domain.TransactionDocument{}, domain.ErrTransactionNotPaid
+	}
+
+	content, err := invoice.Render(buildDocument(*transaction, invoice.KindReceipt))
+	if err != nil {
+		return domain.TransactionDocument{}, err
+	}
+
+	return domain.TransactionDocument{
+		Filename: fmt.Sprintf("receipt-%s.pdf", transaction.OrderID),
+		Content:  content,
+	}, nil
+}
+
+// IssueReceipt renders the receipt of a paid transaction, stores it in S3 and
+// emails it to the customer.
+func (s *transactionService) IssueReceipt(ctx context.Context, id string) error {
+	transaction, err := s.transactionRepository.GetTransactionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if transaction.Status != domain.TransactionStatusPaid {
+		return domain.ErrTransactionNotPaid
+	}
+
+	doc := buildDocument(*transaction, invoice.KindReceipt)
+	content, err := invoice.Render(doc)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("receipt-%s.pdf", transaction.OrderID)
+	objectKey, err := s.blobStore.UploadBytes("transaction-receipts/"+filename, content, "application/pdf")
+	if err != nil {
+		return err
+	}
+	if err := s.transactionRepository.UpdateReceiptURL(ctx, transaction.ID.String(), objectKey); err != nil {
+		return err
+	}
+
+	if transaction.User == nil {
+		return nil
+	}
+
+	readHtml, err := os.ReadFile("internal/utils/mailing/template/payment_receipt.html")
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, map[string]any{
+		"Name":    transaction.User.Name,
+		"Amount":  invoice.FormatRupiah(doc.Total()),
+		"OrderID": transaction.OrderID,
+	}); err != nil {
+		return err
+	}
+
+	return mailing.SendMailWithAttachments(transaction.User.Email, "Payment Receipt for Foodia", strMail.String(), mailing.Attachment{
+		Filename: filename,
+		Data:     content,
+	})
+}
+
+func (s *transactionService) getOwnedTransaction(ctx context.Context, id string, userID string) (*entities.Transaction, error) {
+	transaction, err := s.transactionRepository.GetTransactionByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if transaction.UserID == nil || transaction.UserID.String() != userID {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+func buildDocument(transaction entities.Transaction, kind string) invoice.Document {
+	doc := invoice.Document{
+		Kind:     kind,
+		Number:   transaction.OrderID,
+		IssuedAt: transaction.CreatedAt,
+		PaidAt:   transaction.PaidAt,
+		Status:   transaction.Status,
+		Lines: []invoice.Line{
+			{
+				Description: subscriptionDescription,
+				Quantity:    1,
+				UnitPrice:   transaction.Amount,
+			},
+		},
+	}
+	if transaction.User != nil {
+		doc.CustomerName = transaction.User.Name
+		doc.CustomerEmail = transaction.User.Email
+	}
+	return doc
+}
+
+func (s *transactionService) toTransactionResponse(transaction entities.Transaction) domain.TransactionResponse {
+	return domain.TransactionResponse{
+		ID:         transaction.ID.String(),
+		OrderID:    transaction.OrderID,
+		Status:     transaction.Status,
+		Amount:     transaction.Amount,
+		PaymentURL: transaction.Invoice,
+		ReceiptURL: s.blobStore.SignedLink(transaction.ReceiptURL),
+		PaidAt:     transaction.PaidAt,
+		CreatedAt:  transaction.CreatedAt,
+	}
+}
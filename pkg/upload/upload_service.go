@@ -0,0 +1,296 @@
+package upload
+
+import (
+	"Go-Starter-Template/domain"
+	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/internal/utils/imaging"
+	"Go-Starter-Template/internal/utils/pdfpages"
+	"Go-Starter-Template/internal/utils/storage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"log"
+	"mime/multipart"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const pdfContentType = "application/pdf"
+
+// uploadURLTTL is how long a client has to PUT the file once it asked for
+// the URL. Keys left behind are removed by a lifecycle rule on uploads/.
+const uploadURLTTL = 15 * time.Minute
+
+type (
+	// UploadService hands out presigned PUT URLs and turns what the client
+	// sent, a multipart file or the key it uploaded to, into checked and
+	// processed files.
+	UploadService interface {
+		CreateUpload(ctx context.Context, userID string, req domain.CreateUploadRequest) (domain.CreateUploadResponse, error)
+		Resolve(ctx context.Context, userID string, purpose string, f *multipart.FileHeader, objectKey string) (Upload, error)
+		ResolvePages(ctx context.Context, userID string, purpose string, files []*multipart.FileHeader, objectKeys []string) ([]Upload, error)
+		Store(objectKey string, upload Upload) error
+		Release(objectKey string)
+	}
+
+	// Upload is a resolved upload. File is what gets stored, Vision the
+	// smaller copy for the vision provider and Variants the thumbnails
+	// stored next to File, see imaging.VariantKey. A PDF page that is not
+	// a picture is kept as a PDF, File and Vision are then the same and
+	// DHash is 0.
+	Upload struct {
+		File     storage.File
+		Vision   storage.File
+		Variants map[string]storage.File
+		DHash    uint64
+	}
+
+	uploadService struct {
+		blobStore   storage.BlobStore
+		imageConfig imaging.Config
+		maxPages    int
+	}
+
+	rule struct {
+		types      []string
+		maxSize    int64
+		thumbnails bool
+	}
+)
+
+var rules = map[string]rule{
+	domain.UploadPurposeFoodImage:      {types: storage.AllowImage, maxSize: 20 << 20, thumbnails: true},
+	domain.UploadPurposeReceipt:        {types: storage.AllowImagePdf, maxSize: 20 << 20, thumbnails: true},
+	domain.UploadPurposeProfilePicture: {types: storage.AllowImage, maxSize: 10 << 20},
+}
+
+func NewUploadService(blobStore storage.BlobStore) UploadService {
+	return &uploadService{
+		blobStore:   blobStore,
+		imageConfig: imaging.LoadConfig(),
+		maxPages:    utils.GetIntConfig("UPLOAD_MAX_PAGES", 10),
+	}
+}
+
+func (s *uploadService) CreateUpload(ctx context.Context, userID string, req domain.CreateUploadRequest) (domain.CreateUploadResponse, error) {
+	r, ok := rules[req.Purpose]
+	if !ok {
+		return domain.CreateUploadResponse{}, domain.ErrUploadPurposeInvalid
+	}
+	if !slices.Contains(r.types, req.ContentType) {
+		return domain.CreateUploadResponse{}, domain.ErrUploadTypeNotAllowed
+	}
+	if req.Size > r.maxSize {
+		return domain.CreateUploadResponse{}, domain.ErrUploadTooLarge
+	}
+
+	objectKey := keyPrefix(userID, req.Purpose) + uuid.New().String()
+	uploadURL, err := s.blobStore.PresignPutURL(objectKey, uploadURLTTL, req.ContentType, req.Size)
+	if err != nil {
+		return domain.CreateUploadResponse{}, err
+	}
+
+	return domain.CreateUploadResponse{
+		ObjectKey: objectKey,
+		UploadURL: uploadURL,
+		Method:    fiber.MethodPut,
+		Headers:   map[string]string{fiber.HeaderContentType: req.ContentType},
+		ExpiresAt: time.Now().Add(uploadURLTTL),
+	}, nil
+}
+
+// Resolve returns the file for purpose, read from f when given and else
+// from the presigned upload at objectKey, which has to be one of userID's.
+// Images come back re-encoded by imaging.Process, a PDF is not accepted
+// here, see ResolvePages.
+func (s *uploadService) Resolve(ctx context.Context, userID string, purpose string, f *multipart.FileHeader, objectKey string) (Upload, error) {
+	r, ok := rules[purpose]
+	if !ok {
+		return Upload{}, domain.ErrUploadPurposeInvalid
+	}
+
+	file, err := s.read(userID, purpose, r, f, objectKey)
+	if err != nil {
+		return Upload{}, err
+	}
+	if file.ContentType == pdfContentType {
+		return Upload{}, domain.ErrUploadTypeNotAllowed
+	}
+	return s.processImage(file.Data, r)
+}
+
+// ResolvePages resolves the parts of one document sent as several files,
+// files first and then objectKeys, each in order. A PDF among them adds
+// each of its pages.
+func (s *uploadService) ResolvePages(ctx context.Context, userID string, purpose string, files []*multipart.FileHeader, objectKeys []string) ([]Upload, error) {
+	r, ok := rules[purpose]
+	if !ok {
+		return nil, domain.ErrUploadPurposeInvalid
+	}
+	if len(files)+len(objectKeys) == 0 {
+		return nil, storage.ErrNoFile
+	}
+	if len(files)+len(objectKeys) > s.maxPages {
+		return nil, domain.ErrUploadTooManyPages
+	}
+
+	var pages []Upload
+	add := func(f *multipart.FileHeader, objectKey string) error {
+		file, err := s.read(userID, purpose, r, f, objectKey)
+		if err != nil {
+			return err
+		}
+
+		if file.ContentType != pdfContentType {
+			page, err := s.processImage(file.Data, r)
+			if err != nil {
+				return err
+			}
+			pages = append(pages, page)
+			return nil
+		}
+
+		// Split reads a limit of 0 as none at all
+		remaining := s.maxPages - len(pages)
+		if remaining <= 0 {
+			return domain.ErrUploadTooManyPages
+		}
+		pdfPages, err := pdfpages.Split(file.Data, remaining)
+		if err != nil {
+			if errors.Is(err, pdfpages.ErrTooManyPages) {
+				return domain.ErrUploadTooManyPages
+			}
+			return err
+		}
+		for _, pdfPage := range pdfPages {
+			if pdfPage.Image != nil {
+				page, err := s.processImage(pdfPage.Image, r)
+				if err != nil {
+					return err
+				}
+				pages = append(pages, page)
+				continue
+			}
+			document := storage.File{Data: pdfPage.PDF, ContentType: pdfContentType}
+			pages = append(pages, Upload{File: document, Vision: document})
+		}
+		return nil
+	}
+
+	for _, f := range files {
+		if err := add(f, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, objectKey := range objectKeys {
+		if err := add(nil, objectKey); err != nil {
+			return nil, err
+		}
+	}
+	if len(pages) > s.maxPages {
+		return nil, domain.ErrUploadTooManyPages
+	}
+	return pages, nil
+}
+
+// read loads the bytes of an upload, the size and the sniffed type are
+// checked for both sources since a presigned PUT only promised them.
+func (s *uploadService) read(userID string, purpose string, r rule, f *multipart.FileHeader, objectKey string) (storage.File, error) {
+	var data []byte
+	switch {
+	case f != nil:
+		if f.Size > r.maxSize {
+			return storage.File{}, domain.ErrUploadTooLarge
+		}
+		file, err := storage.ReadUpload(f)
+		if err != nil {
+			return storage.File{}, err
+		}
+		data = file.Data
+	case objectKey != "":
+		// the local driver cleans keys, a ../ would climb out of the prefix
+		if path.Clean(objectKey) != objectKey || !strings.HasPrefix(objectKey, keyPrefix(userID, purpose)) {
+			return storage.File{}, domain.ErrUploadNotFound
+		}
+		var err error
+		data, err = s.blobStore.GetFileLimited(objectKey, r.maxSize)
+		if err != nil {
+			if errors.Is(err, storage.ErrObjectNotFound) {
+				return storage.File{}, domain.ErrUploadNotFound
+			}
+			if errors.Is(err, storage.ErrObjectTooLarge) {
+				return storage.File{}, domain.ErrUploadTooLarge
+			}
+			return storage.File{}, err
+		}
+	default:
+		return storage.File{}, storage.ErrNoFile
+	}
+
+	if int64(len(data)) > r.maxSize {
+		return storage.File{}, domain.ErrUploadTooLarge
+	}
+	file, err := storage.CheckFile(data, r.types...)
+	if err != nil {
+		return storage.File{}, domain.ErrUploadTypeNotAllowed
+	}
+	return file, nil
+}
+
+func (s *uploadService) processImage(data []byte, r rule) (Upload, error) {
+	config := s.imageConfig
+	if !r.thumbnails {
+		config.Variants = nil
+	}
+	result, err := imaging.Process(data, config)
+	if err != nil {
+		return Upload{}, err
+	}
+
+	upload := Upload{
+		File:     imageFile(result.Image),
+		Vision:   imageFile(result.Vision),
+		Variants: make(map[string]storage.File, len(result.Variants)),
+		DHash:    result.DHash,
+	}
+	for name, variant := range result.Variants {
+		upload.Variants[name] = imageFile(variant)
+	}
+	return upload, nil
+}
+
+// Store writes the file of upload to objectKey and its variants next to it.
+func (s *uploadService) Store(objectKey string, upload Upload) error {
+	if _, err := s.blobStore.UploadBytes(objectKey, upload.File.Data, upload.File.ContentType); err != nil {
+		return err
+	}
+	for name, variant := range upload.Variants {
+		if _, err := s.blobStore.UploadBytes(imaging.VariantKey(objectKey, name), variant.Data, variant.ContentType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release removes a presigned upload once its bytes were copied to where
+// they belong.
+func (s *uploadService) Release(objectKey string) {
+	if objectKey == "" {
+		return
+	}
+	if err := s.blobStore.DeleteFile(objectKey); err != nil {
+		log.Printf("upload: failed to delete %s: %v", objectKey, err)
+	}
+}
+
+func imageFile(image imaging.Image) storage.File {
+	return storage.File{Data: image.Data, ContentType: imaging.ContentType}
+}
+
+func keyPrefix(userID, purpose string) string {
+	return fmt.Sprintf("uploads/%s/%s/", userID, purpose)
+}
@@ -4,7 +4,10 @@ import (
 	"Go-Starter-Template/entities"
 	"context"
 	"errors"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type (
@@ -13,8 +16,13 @@ type (
 		GetEmail(ctx context.Context, email string) (*entities.User, error)
 		UpdateUser(ctx context.Context, user entities.User) (*entities.User, error)
 		GetUserByID(ctx context.Context, id string) (*entities.User, error)
-		UpdateSubscriptionStatus(ctx context.Context, userID string) error
-		UpdatePassword(ctx context.Context, email string, newPassword string) error
+		UpdateSubscriptionStatus(ctx context.Context, userID string, subscribe bool) error
+		UpdatePassword(ctx context.Context, userID string, newPassword string, oldPassword string, keep int) error
+		GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
+		SetDeletionSchedule(ctx context.Context, userID string, scheduledAt *time.Time) error
+		GetUsersDueForDeletion(ctx context.Context, now time.Time) ([]entities.User, error)
+		GetOwnedFiles(ctx context.Context, userID string) (links []string, objectKeys []string, err error)
+		DeleteUserPermanently(ctx context.Context, user entities.User) error
 	}
 	userRepository struct {
 		db *gorm.DB
@@ -58,21 +66,174 @@ func (r *userRepository) GetUserByID(ctx context.Context, id string) (*entities.
 	return &user, nil
 }
 
-func (r *userRepository) UpdateSubscriptionStatus(ctx context.Context, userID string) error {
+func (r *userRepository) UpdateSubscriptionStatus(ctx context.Context, userID string, subscribe bool) error {
 	if err := r.db.WithContext(ctx).
 		Model(&entities.User{}).
 		Where("id = ?", userID).
-		Updates(map[string]interface{}{"subscribe": true}).Error; err != nil {
+		Updates(map[string]interface{}{"subscribe": subscribe}).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
-func (r *userRepository) UpdatePassword(ctx context.Context, email string, newPassword string) error {
-	if err := r.db.WithContext(ctx).Model(&entities.User{}).
-		Where("email = ?", email).
-		Update("password", newPassword).Error; err != nil {
-		return err
+// UpdatePassword sets the new hash and moves the old one into the history,
+// keeping only the latest keep entries.
+func (r *userRepository) UpdatePassword(ctx context.Context, userID string, newPassword string, oldPassword string, keep int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.User{}).
+			Where("id = ?", userID).
+			Update("password", newPassword).Error; err != nil {
+			return err
+		}
+
+		// accounts created through a provider have no previous password
+		if oldPassword == "" || keep <= 0 {
+			return nil
+		}
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&entities.PasswordHistory{
+			UserID:       id,
+			PasswordHash: oldPassword,
+			CreatedAt:    time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ? AND id NOT IN (?)", userID,
+			tx.Model(&entities.PasswordHistory{}).
+				Select("id").
+				Where("user_id = ?", userID).
+				Order("created_at DESC").
+				Limit(keep),
+		).Delete(&entities.PasswordHistory{}).Error
+	})
+}
+
+func (r *userRepository) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	var hashes []string
+	if limit <= 0 {
+		return hashes, nil
 	}
-	return nil
+	if err := r.db.WithContext(ctx).
+		Model(&entities.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (r *userRepository) SetDeletionSchedule(ctx context.Context, userID string, scheduledAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.User{}).
+		Where("id = ?", userID).
+		Update("deletion_scheduled_at", scheduledAt).Error
+}
+
+func (r *userRepository) GetUsersDueForDeletion(ctx context.Context, now time.Time) ([]entities.User, error) {
+	var users []entities.User
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at < ?", now).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetOwnedFiles lists what the user has in the bucket. Uploads are stored as
+// public links, data exports as bare object keys.
+func (r *userRepository) GetOwnedFiles(ctx context.Context, userID string) ([]string, []string, error) {
+	var links []string
+	for _, model := range []any{&entities.FoodItem{}, &entities.ReceiptScan{}} {
+		var modelLinks []string
+		if err := r.db.WithContext(ctx).Unscoped().Model(model).
+			Where("user_id = ? AND image_url <> ''", userID).
+			Pluck("image_url", &modelLinks).Error; err != nil {
+			return nil, nil, err
+		}
+		links = append(links, modelLinks...)
+	}
+
+	var receipts []string
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entities.Transaction{}).
+		Where("user_id = ? AND receipt_url <> ''", userID).
+		Pluck("receipt_url", &receipts).Error; err != nil {
+		return nil, nil, err
+	}
+	links = append(links, receipts...)
+
+	var pages []string
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entities.ReceiptPage{}).
+		Where("receipt_scan_id IN (?)", r.db.Unscoped().Model(&entities.ReceiptScan{}).Select("id").Where("user_id = ?", userID)).
+		Pluck("object_key", &pages).Error; err != nil {
+		return nil, nil, err
+	}
+	links = append(links, pages...)
+
+	var objectKeys []string
+	if err := r.db.WithContext(ctx).Unscoped().Model(&entities.DataExport{}).
+		Where("user_id = ? AND object_key <> ''", userID).
+		Pluck("object_key", &objectKeys).Error; err != nil {
+		return nil, nil, err
+	}
+	return links, objectKeys, nil
+}
+
+// DeleteUserPermanently erases the user and every row they own. Transactions
+// are kept for the books with the owner and receipt removed, audit logs are
+// append only and stay as they are, they only hold the user's ID.
+func (r *userRepository) DeleteUserPermanently(ctx context.Context, user entities.User) error {
+	userID := user.ID.String()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		owned := []any{
+			&entities.FoodItem{},
+			&entities.ReceiptScan{},
+			&entities.ReceiptCorrection{},
+			&entities.Session{},
+			&entities.UserIdentity{},
+			&entities.RecoveryCode{},
+			&entities.PasswordHistory{},
+			&entities.OneTimeToken{},
+			&entities.OAuthState{},
+			&entities.DataExport{},
+		}
+		if err := tx.Unscoped().
+			Where("receipt_scan_id IN (?)", tx.Unscoped().Model(&entities.ReceiptScan{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&entities.ReceiptPage{}).Error; err != nil {
+			return err
+		}
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&entities.Transaction{}).Unscoped().
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"user_id":     nil,
+				"receipt_url": "",
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.PaymentEvent{}).
+			Where("actor_id = ?", userID).
+			Update("actor_id", nil).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("key = ?", "account:"+strings.ToLower(strings.TrimSpace(user.Email))).
+			Delete(&entities.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&entities.User{}, "id = ?", userID).Error
+	})
 }
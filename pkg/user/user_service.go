@@ -4,13 +4,22 @@ import (
 	"Go-Starter-Template/domain"
 	"Go-Starter-Template/entities"
 	"Go-Starter-Template/internal/utils"
+	"Go-Starter-Template/internal/utils/imaging"
 	"Go-Starter-Template/internal/utils/mailing"
+	"Go-Starter-Template/internal/utils/passwordpolicy"
 	"Go-Starter-Template/internal/utils/storage"
-	"Go-Starter-Template/pkg/jwt"
+	"Go-Starter-Template/pkg/audit"
+	"Go-Starter-Template/pkg/lockout"
+	"Go-Starter-Template/pkg/mfa"
+	"Go-Starter-Template/pkg/onetimetoken"
+	"Go-Starter-Template/pkg/session"
+	"Go-Starter-Template/pkg/upload"
 	"bytes"
 	"context"
+	"encoding/json"
 	"github.com/google/uuid"
 	"html/template"
+	"log"
 	"os"
 	"strings"
 	"time"
@@ -19,31 +28,62 @@ import (
 type (
 	UserService interface {
 		Register(ctx context.Context, req domain.UserRegisterRequest) (domain.UserRegisterResponse, error)
-		Login(ctx context.Context, req domain.UserLoginRequest) (domain.UserLoginResponse, error)
+		Login(ctx context.Context, req domain.UserLoginRequest, client domain.SessionClient) (domain.UserLoginResponse, error)
 		SendVerificationEmail(ctx context.Context, req domain.SendVerifyEmailRequest) error
 		VerifyEmail(ctx context.Context, req domain.VerifyEmailRequest) (domain.VerifyEmailResponse, error)
 		Me(ctx context.Context, userID string) (domain.DetailUserResponse, error)
 		Update(ctx context.Context, req domain.UpdateUserRequest, userID string) (domain.UpdateUserResponse, error)
 		ForgetPassword(ctx context.Context, req domain.ForgetPasswordRequest) error
-		ResetPassword(ctx context.Context, email, password string) error
+		ResetPassword(ctx context.Context, token, password string) error
+		ChangePassword(ctx context.Context, userID, sessionID string, req domain.ChangePasswordRequest) error
+		UnlockAccount(ctx context.Context, token string) error
+		ConfirmEmailChange(ctx context.Context, token string) (domain.UpdateUserResponse, error)
+		RequestDeletion(ctx context.Context, userID, sessionID string, req domain.DeleteAccountRequest) (domain.AccountDeletionResponse, error)
+		CancelDeletion(ctx context.Context, userID string) error
+		PurgeDeletedAccounts(ctx context.Context) error
 	}
 
 	userService struct {
-		userRepository UserRepository
-		jwtService     jwt.JWTService
-		S3             storage.AwsS3
+		userRepository      UserRepository
+		oneTimeTokenService onetimetoken.OneTimeTokenService
+		sessionService      session.SessionService
+		mfaService          mfa.MFAService
+		lockoutService      lockout.LockoutService
+		auditService        audit.AuditService
+		passwordPolicy      passwordpolicy.Policy
+		deletionGrace       time.Duration
+		blobStore           storage.BlobStore
+		uploadService       upload.UploadService
 	}
 )
 
-func NewUserService(userRepository UserRepository, jwtService jwt.JWTService, s3 storage.AwsS3) UserService {
+func NewUserService(userRepository UserRepository, oneTimeTokenService onetimetoken.OneTimeTokenService, sessionService session.SessionService, mfaService mfa.MFAService, lockoutService lockout.LockoutService, auditService audit.AuditService, blobStore storage.BlobStore, uploadService upload.UploadService) UserService {
 	return &userService{
-		userRepository: userRepository,
-		jwtService:     jwtService,
-		S3:             s3,
+		userRepository:      userRepository,
+		oneTimeTokenService: oneTimeTokenService,
+		sessionService:      sessionService,
+		mfaService:          mfaService,
+		lockoutService:      lockoutService,
+		auditService:        auditService,
+		passwordPolicy:      passwordpolicy.LoadPolicy(),
+		deletionGrace:       utils.GetDurationConfig("ACCOUNT_DELETION_GRACE", 14*24*time.Hour),
+		blobStore:           blobStore,
+		uploadService:       uploadService,
 	}
 }
 
-var VerifyEmailRoute = "api/v1/users/verify"
+var (
+	VerifyEmailRoute        = "api/v1/users/verify"
+	UnlockAccountRoute      = "api/v1/users/unlock"
+	ConfirmEmailChangeRoute = "api/v1/users/email/confirm"
+)
+
+const (
+	verificationTokenTTL  = 24 * time.Hour
+	passwordResetTokenTTL = 30 * time.Minute
+	unlockTokenTTL        = 24 * time.Hour
+	emailChangeTokenTTL   = 24 * time.Hour
+)
 
 func (s *userService) Register(ctx context.Context, req domain.UserRegisterRequest) (domain.UserRegisterResponse, error) {
 	// checking user if exist
@@ -53,6 +93,10 @@ func (s *userService) Register(ctx context.Context, req domain.UserRegisterReque
 		return domain.UserRegisterResponse{}, domain.ErrEmailAlreadyExists
 	}
 
+	if err := s.passwordPolicy.Validate(req.Password, req.Email, req.Username); err != nil {
+		return domain.UserRegisterResponse{}, err
+	}
+
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		return domain.UserRegisterResponse{}, err
@@ -68,50 +112,100 @@ func (s *userService) Register(ctx context.Context, req domain.UserRegisterReque
 		Role:     domain.RoleUser,
 	}
 
-	draftEmail, err := s.makeVerificationEmail(req.Email)
+	if err := s.userRepository.CreateUser(ctx, &user); err != nil {
+		return domain.UserRegisterResponse{}, domain.ErrRegisterUserFailed
+	}
+
+	// the user can ask for a new link through send_verify if this fails
+	draftEmail, err := s.makeVerificationEmail(ctx, user)
 	if err != nil {
 		return domain.UserRegisterResponse{}, err
 	}
-
 	if err := mailing.SendMail(req.Email, draftEmail["subject"], draftEmail["body"]); err != nil {
 		return domain.UserRegisterResponse{}, err
 	}
 
-	if err := s.userRepository.CreateUser(ctx, &user); err != nil {
-		return domain.UserRegisterResponse{}, domain.ErrRegisterUserFailed
-	}
-
 	return domain.UserRegisterResponse{
 		Email:    user.Email,
 		Username: user.Username,
 	}, nil
 }
 
-func (s *userService) Login(ctx context.Context, req domain.UserLoginRequest) (domain.UserLoginResponse, error) {
+func (s *userService) Login(ctx context.Context, req domain.UserLoginRequest, client domain.SessionClient) (domain.UserLoginResponse, error) {
+	if err := s.lockoutService.Check(ctx, req.Email, client.IPAddress); err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+
 	// check email if exist
 	user, err := s.userRepository.GetEmail(ctx, req.Email)
 	if err != nil {
+		return domain.UserLoginResponse{}, err
+	}
+	// unknown emails count as failures too, so lockouts say nothing about
+	// which accounts exist
+	if user == nil || !utils.CheckPassword(req.Password, user.Password) {
+		if err := s.registerLoginFailure(ctx, req.Email, user, client.IPAddress); err != nil {
+			return domain.UserLoginResponse{}, err
+		}
 		return domain.UserLoginResponse{}, domain.CredentialInvalid
 	}
 	if !user.Verified {
 		return domain.UserLoginResponse{}, domain.ErrUserNotVerified
 	}
-	if ok := utils.CheckPassword(req.Password, user.Password); !ok {
-		return domain.UserLoginResponse{}, domain.CredentialInvalid
+	if err := s.lockoutService.RegisterSuccess(ctx, req.Email); err != nil {
+		return domain.UserLoginResponse{}, err
 	}
 
-	token := s.jwtService.GenerateTokenUser(user.ID.String(), user.Role)
+	client.DeviceName = ifNotEmpty(req.DeviceName, client.UserAgent)
+	return s.mfaService.SignIn(ctx, *user, client, []string{domain.AMRPassword})
+}
+
+func (s *userService) registerLoginFailure(ctx context.Context, email string, user *entities.User, ip string) error {
+	locked, err := s.lockoutService.RegisterFailure(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	if !locked || user == nil {
+		return nil
+	}
 
-	return domain.UserLoginResponse{
-		Token: token,
-		Role:  user.Role,
-	}, nil
+	token, err := s.oneTimeTokenService.Issue(ctx, domain.OneTimeTokenRequest{
+		Purpose: domain.TokenPurposeAccountUnlock,
+		UserID:  &user.ID,
+		Subject: user.Email,
+		TTL:     unlockTokenTTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := renderTemplate("internal/utils/mailing/template/account_locked.html", map[string]any{
+		"Name":       user.Name,
+		"UnlockLink": utils.GetConfig("APP_URL") + "/" + UnlockAccountRoute + "?token=" + token,
+	})
+	if err != nil {
+		return err
+	}
+	return mailing.SendMail(user.Email, "Your Foodia account has been locked", body)
 }
 
-func (s *userService) makeVerificationEmail(email string) (map[string]string, error) {
-	expired := time.Now().Add(time.Hour * 24).Format("2006-01-02 15:04:05")
-	plainText := email + "_" + expired
-	token, err := utils.AESEncrypt(plainText)
+// UnlockAccount lifts the lock of the account the emailed link was sent for.
+func (s *userService) UnlockAccount(ctx context.Context, token string) error {
+	consumed, err := s.oneTimeTokenService.Consume(ctx, domain.TokenPurposeAccountUnlock, token)
+	if err != nil {
+		return err
+	}
+	return s.lockoutService.Unlock(ctx, consumed.Subject)
+}
+
+func (s *userService) makeVerificationEmail(ctx context.Context, user entities.User) (map[string]string, error) {
+	email := user.Email
+	token, err := s.oneTimeTokenService.Issue(ctx, domain.OneTimeTokenRequest{
+		Purpose: domain.TokenPurposeEmailVerification,
+		UserID:  &user.ID,
+		Subject: email,
+		TTL:     verificationTokenTTL,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -152,11 +246,14 @@ func (s *userService) makeVerificationEmail(email string) (map[string]string, er
 
 func (s *userService) SendVerificationEmail(ctx context.Context, req domain.SendVerifyEmailRequest) error {
 	user, err := s.userRepository.GetEmail(ctx, req.Email)
-	if err != nil {
+	if err != nil || user == nil {
 		return domain.ErrEmailNotFound
 	}
+	if user.Verified {
+		return domain.ErrAccountAlreadyVerified
+	}
 
-	draftEmail, err := s.makeVerificationEmail(user.Email)
+	draftEmail, err := s.makeVerificationEmail(ctx, *user)
 	if err != nil {
 		return err
 	}
@@ -167,36 +264,19 @@ func (s *userService) SendVerificationEmail(ctx context.Context, req domain.Send
 }
 
 func (s *userService) VerifyEmail(ctx context.Context, req domain.VerifyEmailRequest) (domain.VerifyEmailResponse, error) {
-	decryptToken, err := utils.AESDecrypt(req.Token)
+	token, err := s.oneTimeTokenService.Consume(ctx, domain.TokenPurposeEmailVerification, req.Token)
 	if err != nil {
 		return domain.VerifyEmailResponse{}, err
 	}
 
-	tokenParts := strings.Split(decryptToken, "_")
-	if len(tokenParts) < 2 {
-		return domain.VerifyEmailResponse{}, domain.ErrTokenInvalid
-	}
-
-	email := tokenParts[0]
-	expirationDate := tokenParts[1]
-	expirationTime, err := time.Parse("2006-01-02 15:04:05", expirationDate)
-	if err != nil {
-		return domain.VerifyEmailResponse{}, domain.ErrTokenInvalid
-	}
-
-	now := time.Now()
-
-	if expirationTime.Before(now) {
-		return domain.VerifyEmailResponse{
-			Email:      email,
-			IsVerified: false,
-		}, domain.ErrTokenExpired
-	}
-
-	user, err := s.userRepository.GetEmail(ctx, email)
+	user, err := s.userRepository.GetUserByID(ctx, token.UserID.String())
 	if err != nil {
 		return domain.VerifyEmailResponse{}, domain.ErrEmailNotFound
 	}
+	// the link only proves ownership of the address it was sent to
+	if user.Email != token.Subject {
+		return domain.VerifyEmailResponse{}, domain.ErrTokenInvalid
+	}
 
 	if user.Verified {
 		return domain.VerifyEmailResponse{}, domain.ErrAccountAlreadyVerified
@@ -231,8 +311,11 @@ func (s *userService) Me(ctx context.Context, userID string) (domain.DetailUserR
 		Username:       user.Username,
 		Email:          user.Email,
 		Contact:        user.Contact,
-		ProfilePicture: user.ProfilePicture,
+		ProfilePicture: s.blobStore.SignedLink(user.ProfilePicture),
 		Subscription:   user.Subscribe,
+		MFAEnabled:     user.MFAEnabled,
+
+		DeletionScheduledAt: user.DeletionScheduledAt,
 	}, nil
 }
 
@@ -241,19 +324,24 @@ func (s *userService) Update(ctx context.Context, req domain.UpdateUserRequest,
 	if err != nil {
 		return domain.UpdateUserResponse{}, domain.ErrUserNotFound
 	}
+	before := *user
 
-	if user.ProfilePicture != "" {
-		updatedKey, err := s.S3.UpdateFile(s.S3.GetObjectKeyFromLink(user.ProfilePicture), req.ProfilePicture, storage.AllowImage...)
+	if req.ProfilePicture != nil || req.ProfilePictureKey != "" {
+		file, err := s.uploadService.Resolve(ctx, userID, domain.UploadPurposeProfilePicture, req.ProfilePicture, req.ProfilePictureKey)
 		if err != nil {
 			return domain.UpdateUserResponse{}, err
 		}
-		user.ProfilePicture = s.S3.GetPublicLinkKey(updatedKey)
-	} else if user.ProfilePicture == "" {
-		objectKey, err := s.S3.UploadFile("ProfilePicture-"+user.ID.String(), req.ProfilePicture, "profile-picture", storage.AllowImage...)
-		if err != nil {
+
+		// a provider avatar is a link elsewhere, the upload gets a key of its own
+		objectKey := s.blobStore.GetObjectKeyFromLink(user.ProfilePicture)
+		if objectKey == "" {
+			objectKey = "profile-picture/ProfilePicture-" + user.ID.String()
+		}
+		if err := s.uploadService.Store(objectKey, file); err != nil {
 			return domain.UpdateUserResponse{}, err
 		}
-		user.ProfilePicture = s.S3.GetPublicLinkKey(objectKey)
+		s.uploadService.Release(req.ProfilePictureKey)
+		user.ProfilePicture = objectKey
 	}
 
 	// validation if the user who's updating is the valid user
@@ -265,9 +353,17 @@ func (s *userService) Update(ctx context.Context, req domain.UpdateUserRequest,
 		return domain.UpdateUserResponse{}, domain.ErrUserNotValid
 	}
 
+	// a new email is only stored once it is confirmed, see ConfirmEmailChange
+	var pendingEmail string
+	if req.Email != "" && !strings.EqualFold(req.Email, user.Email) {
+		if err := s.requestEmailChange(ctx, *user, req.Email); err != nil {
+			return domain.UpdateUserResponse{}, err
+		}
+		pendingEmail = req.Email
+	}
+
 	user.Name = ifNotEmpty(req.Name, user.Name)
 	user.Username = ifNotEmpty(req.Username, user.Username)
-	user.Email = ifNotEmpty(req.Email, user.Email)
 	user.Contact = ifNotEmpty(req.Contact, user.Contact)
 
 	upd, err := s.userRepository.UpdateUser(ctx, *user)
@@ -275,27 +371,162 @@ func (s *userService) Update(ctx context.Context, req domain.UpdateUserRequest,
 		return domain.UpdateUserResponse{}, err
 	}
 
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserUpdated,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		Before:     before,
+		After:      *user,
+	})
+
 	return domain.UpdateUserResponse{
 		Name:           upd.Name,
 		Username:       upd.Username,
 		Email:          upd.Email,
 		Contact:        upd.Contact,
-		ProfilePicture: upd.ProfilePicture,
+		ProfilePicture: s.blobStore.SignedLink(upd.ProfilePicture),
+		PendingEmail:   pendingEmail,
 	}, nil
 }
 
+// requestEmailChange sends a confirmation link to the new address and a
+// notice to the current one. Asking again replaces the pending change.
+func (s *userService) requestEmailChange(ctx context.Context, user entities.User, newEmail string) error {
+	if existing, err := s.userRepository.GetEmail(ctx, newEmail); err != nil {
+		return err
+	} else if existing != nil {
+		return domain.ErrEmailAlreadyExists
+	}
+
+	token, err := s.oneTimeTokenService.Issue(ctx, domain.OneTimeTokenRequest{
+		Purpose: domain.TokenPurposeEmailChange,
+		UserID:  &user.ID,
+		Subject: newEmail,
+		Payload: domain.EmailChangePayload{OldEmail: user.Email},
+		TTL:     emailChangeTokenTTL,
+	})
+	if err != nil {
+		return err
+	}
+
+	appUrl := utils.GetConfig("APP_URL")
+	confirmMail, err := renderTemplate("internal/utils/mailing/template/email_change_confirm.html", map[string]any{
+		"Name":        user.Name,
+		"NewEmail":    newEmail,
+		"ConfirmLink": appUrl + "/" + ConfirmEmailChangeRoute + "?token=" + token,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mailing.SendMail(newEmail, "Confirm your new Foodia email", confirmMail); err != nil {
+		return err
+	}
+
+	noticeMail, err := renderTemplate("internal/utils/mailing/template/email_change_notice.html", map[string]any{
+		"Name":        user.Name,
+		"MaskedEmail": maskEmail(newEmail),
+		"ResetLink":   appUrl + "/reset",
+	})
+	if err != nil {
+		return err
+	}
+	return mailing.SendMail(user.Email, "Your Foodia email is being changed", noticeMail)
+}
+
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (domain.UpdateUserResponse, error) {
+	consumed, err := s.oneTimeTokenService.Consume(ctx, domain.TokenPurposeEmailChange, token)
+	if err != nil {
+		return domain.UpdateUserResponse{}, err
+	}
+
+	var payload domain.EmailChangePayload
+	if err := json.Unmarshal([]byte(consumed.Payload), &payload); err != nil {
+		return domain.UpdateUserResponse{}, domain.ErrTokenInvalid
+	}
+
+	user, err := s.userRepository.GetUserByID(ctx, consumed.UserID.String())
+	if err != nil {
+		return domain.UpdateUserResponse{}, domain.ErrUserNotFound
+	}
+	// the email changed some other way since the link was sent
+	if user.Email != payload.OldEmail {
+		return domain.UpdateUserResponse{}, domain.ErrTokenInvalid
+	}
+	if existing, err := s.userRepository.GetEmail(ctx, consumed.Subject); err != nil {
+		return domain.UpdateUserResponse{}, err
+	} else if existing != nil {
+		return domain.UpdateUserResponse{}, domain.ErrEmailAlreadyExists
+	}
+
+	user.Email = consumed.Subject
+	upd, err := s.userRepository.UpdateUser(ctx, entities.User{ID: user.ID, Email: user.Email})
+	if err != nil {
+		return domain.UpdateUserResponse{}, err
+	}
+	// reset links went to the old inbox, which the user is leaving behind
+	if err := s.oneTimeTokenService.Revoke(ctx, user.ID, domain.TokenPurposePasswordReset); err != nil {
+		return domain.UpdateUserResponse{}, err
+	}
+
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserEmailChanged,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   user.ID.String(),
+		Before:     map[string]any{"email": payload.OldEmail},
+		After:      map[string]any{"email": user.Email},
+	})
+
+	return domain.UpdateUserResponse{
+		Name:           user.Name,
+		Username:       user.Username,
+		Email:          upd.Email,
+		Contact:        user.Contact,
+		ProfilePicture: s.blobStore.SignedLink(user.ProfilePicture),
+	}, nil
+}
+
+func renderTemplate(path string, data any) (string, error) {
+	readHtml, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New("custom").Parse(string(readHtml))
+	if err != nil {
+		return "", err
+	}
+
+	var strMail bytes.Buffer
+	if err := tmpl.Execute(&strMail, data); err != nil {
+		return "", err
+	}
+	return strMail.String(), nil
+}
+
+// maskEmail keeps enough of the address for the owner to recognise it
+// without handing it to whoever reads the old inbox.
+func maskEmail(email string) string {
+	local, domainPart, ok := strings.Cut(email, "@")
+	if !ok || len(local) == 0 {
+		return email
+	}
+	return local[:1] + strings.Repeat("*", max(len(local)-1, 3)) + "@" + domainPart
+}
+
 func (s *userService) ForgetPassword(ctx context.Context, req domain.ForgetPasswordRequest) error {
 	user, err := s.userRepository.GetEmail(ctx, req.Email)
-	if err != nil {
+	if err != nil || user == nil {
 		return domain.ErrEmailNotFound
 	}
 	if user.Verified != true {
 		return domain.ErrUserNotVerified
 	}
 
-	token, err := s.jwtService.GenerateTokenForgetPassword(map[string]any{
-		"email": req.Email,
-	}, time.Minute*5)
+	token, err := s.oneTimeTokenService.Issue(ctx, domain.OneTimeTokenRequest{
+		Purpose: domain.TokenPurposePasswordReset,
+		UserID:  &user.ID,
+		Subject: user.Email,
+		TTL:     passwordResetTokenTTL,
+	})
 	if err != nil {
 		return err
 	}
@@ -331,22 +562,101 @@ func (s *userService) ForgetPassword(ctx context.Context, req domain.ForgetPassw
 	return nil
 }
 
-func (s *userService) ResetPassword(ctx context.Context, email, password string) error {
-	user, err := s.userRepository.GetEmail(ctx, email)
+func (s *userService) ResetPassword(ctx context.Context, token, password string) error {
+	// a rejected password must not spend the emailed link
+	live, err := s.oneTimeTokenService.Peek(ctx, domain.TokenPurposePasswordReset, token)
 	if err != nil {
 		return err
 	}
 
-	if user == nil {
+	user, err := s.userRepository.GetUserByID(ctx, live.UserID.String())
+	if err != nil {
 		return domain.ErrUserNotFound
 	}
+	// a link sent to an address the account has since moved away from
+	if user.Email != live.Subject {
+		return domain.ErrTokenInvalid
+	}
+	if err := s.checkNewPassword(ctx, *user, password); err != nil {
+		return err
+	}
+
+	if _, err := s.oneTimeTokenService.Consume(ctx, domain.TokenPurposePasswordReset, token); err != nil {
+		return err
+	}
+	if err := s.storePassword(ctx, *user, password); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserPasswordReset,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   user.ID.String(),
+	})
+
+	// a reset means the old password may be compromised, sign out everywhere
+	return s.sessionService.RevokeAllSessions(ctx, user.ID.String(), "")
+}
+
+func (s *userService) ChangePassword(ctx context.Context, userID, sessionID string, req domain.ChangePasswordRequest) error {
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+
+	if user.Password != "" && !utils.CheckPassword(req.CurrentPassword, user.Password) {
+		return domain.ErrCurrentPasswordInvalid
+	}
+
+	if err := s.setPassword(ctx, *user, req.NewPassword); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserPasswordChanged,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+	})
+
+	// keep the session that made the change, sign out everything else
+	return s.sessionService.RevokeAllSessions(ctx, userID, sessionID)
+}
+
+// setPassword applies the password policy, including the reuse check against
+// the current hash and the stored history, then saves the new hash.
+func (s *userService) setPassword(ctx context.Context, user entities.User, password string) error {
+	if err := s.checkNewPassword(ctx, user, password); err != nil {
+		return err
+	}
+	return s.storePassword(ctx, user, password)
+}
+
+// checkNewPassword applies the password policy and the history.
+func (s *userService) checkNewPassword(ctx context.Context, user entities.User, password string) error {
+	if err := s.passwordPolicy.Validate(password, user.Email, user.Username); err != nil {
+		return err
+	}
 
+	previous, err := s.userRepository.GetPasswordHistory(ctx, user.ID.String(), s.passwordPolicy.HistorySize)
+	if err != nil {
+		return err
+	}
+	if user.Password != "" {
+		previous = append(previous, user.Password)
+	}
+	for _, hash := range previous {
+		if utils.CheckPassword(password, hash) {
+			return domain.ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+func (s *userService) storePassword(ctx context.Context, user entities.User, password string) error {
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
 		return err
 	}
 
-	return s.userRepository.UpdatePassword(ctx, email, hashedPassword)
+	return s.userRepository.UpdatePassword(ctx, user.ID.String(), hashedPassword, user.Password, s.passwordPolicy.HistorySize)
 }
 
 func ifNotEmpty(value, defaultValue string) string {
@@ -355,3 +665,114 @@ func ifNotEmpty(value, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// RequestDeletion starts the cooling off period. The account keeps working
+// until it ends so the owner can still sign in and cancel.
+func (s *userService) RequestDeletion(ctx context.Context, userID, sessionID string, req domain.DeleteAccountRequest) (domain.AccountDeletionResponse, error) {
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.AccountDeletionResponse{}, domain.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt != nil {
+		return domain.AccountDeletionResponse{}, domain.ErrDeletionScheduled
+	}
+	if user.Password != "" && !utils.CheckPassword(req.Password, user.Password) {
+		return domain.AccountDeletionResponse{}, domain.ErrCurrentPasswordInvalid
+	}
+
+	scheduledAt := time.Now().Add(s.deletionGrace)
+	if err := s.userRepository.SetDeletionSchedule(ctx, userID, &scheduledAt); err != nil {
+		return domain.AccountDeletionResponse{}, err
+	}
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserDeletionRequested,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		After:      map[string]time.Time{"deletion_scheduled_at": scheduledAt},
+	})
+
+	if err := s.sessionService.RevokeAllSessions(ctx, userID, sessionID); err != nil {
+		return domain.AccountDeletionResponse{}, err
+	}
+
+	body, err := renderTemplate("internal/utils/mailing/template/account_deletion_scheduled.html", map[string]any{
+		"Name":        user.Name,
+		"ScheduledAt": scheduledAt.UTC().Format("2 January 2006 15:04 MST"),
+	})
+	if err != nil {
+		return domain.AccountDeletionResponse{}, err
+	}
+	if err := mailing.SendMail(user.Email, "Your Foodia account will be deleted", body); err != nil {
+		return domain.AccountDeletionResponse{}, err
+	}
+
+	return domain.AccountDeletionResponse{DeletionScheduledAt: scheduledAt}, nil
+}
+
+func (s *userService) CancelDeletion(ctx context.Context, userID string) error {
+	user, err := s.userRepository.GetUserByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	if user.DeletionScheduledAt == nil {
+		return domain.ErrDeletionNotScheduled
+	}
+
+	if err := s.userRepository.SetDeletionSchedule(ctx, userID, nil); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, domain.AuditEntry{
+		Action:     domain.AuditUserDeletionCancelled,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   userID,
+		Before:     map[string]time.Time{"deletion_scheduled_at": *user.DeletionScheduledAt},
+	})
+	return nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose cooling off period is over.
+// Files go first, an account whose files could not all be removed is left
+// for the next run so nothing is orphaned in the bucket.
+func (s *userService) PurgeDeletedAccounts(ctx context.Context) error {
+	users, err := s.userRepository.GetUsersDueForDeletion(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		userID := user.ID.String()
+		links, objectKeys, err := s.userRepository.GetOwnedFiles(ctx, userID)
+		if err != nil {
+			return err
+		}
+		links = append(links, user.ProfilePicture)
+		for _, link := range links {
+			// links outside our bucket, such as a provider avatar, map to ""
+			if objectKey := s.blobStore.GetObjectKeyFromLink(link); objectKey != "" {
+				objectKeys = append(objectKeys, objectKey)
+				objectKeys = append(objectKeys, imaging.VariantKeys(objectKey)...)
+			}
+		}
+
+		failed := false
+		for _, objectKey := range objectKeys {
+			if err := s.blobStore.DeleteFile(objectKey); err != nil {
+				log.Printf("account deletion %s: delete %s: %v", userID, objectKey, err)
+				failed = true
+			}
+		}
+		if failed {
+			continue
+		}
+
+		if err := s.userRepository.DeleteUserPermanently(ctx, user); err != nil {
+			return err
+		}
+		s.auditService.Record(ctx, domain.AuditEntry{
+			Action:     domain.AuditUserDeleted,
+			TargetType: domain.AuditTargetUser,
+			TargetID:   userID,
+		})
+	}
+	return nil
+}
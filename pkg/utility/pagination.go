@@ -48,9 +48,9 @@ func AddModels(model any, tablePrefix string) Option {
 		if v.Kind() == reflect.Struct {
 			for i := 0; i < v.NumField(); i++ {
 				field := v.Field(i)
-				jsonTag := field.Tag.Get("json")
-				if jsonTag == "" {
-					jsonTag = field.Name
+				jsonTag := jsonName(field)
+				if jsonTag == "-" {
+					continue
 				}
 
 				fullField := fmt.Sprintf("%s.%s", tablePrefix, jsonTag)
@@ -184,9 +184,9 @@ func addEmbeddedFields(embedType reflect.Type, ms *MetaService, tablePrefix stri
 	if embedType.Kind() == reflect.Struct {
 		for i := 0; i < embedType.NumField(); i++ {
 			field := embedType.Field(i)
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == "" {
-				jsonTag = field.Name
+			jsonTag := jsonName(field)
+			if jsonTag == "-" {
+				continue
 			}
 
 			fullField := fmt.Sprintf("%s.%s", tablePrefix, jsonTag)
@@ -202,3 +202,13 @@ func addEmbeddedFields(embedType reflect.Type, ms *MetaService, tablePrefix stri
 		}
 	}
 }
+
+// jsonName is the key a field is encoded under, the json tag without
+// options such as omitempty, and the field name when the tag has none.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}